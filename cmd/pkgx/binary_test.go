@@ -0,0 +1,30 @@
+package main
+
+import (
+	osexec "os/exec"
+	"path/filepath"
+	"testing"
+
+	. "github.com/xoctopus/x/testx"
+)
+
+// TestBinary runs the built command, which links only what the command uses
+// rather than dependencies of tests
+func TestBinary(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skip building binary in short mode")
+	}
+
+	bin := filepath.Join(t.TempDir(), "pkgx")
+	out, err := osexec.Command("go", "build", "-o", bin, ".").CombinedOutput()
+	Expect(t, err, Succeed())
+	Expect(t, string(out), Equal(""))
+
+	out, err = osexec.Command(bin, "ls", "-C", dir, "./...").CombinedOutput()
+	Expect(t, err, Succeed())
+	Expect(t, string(out), Equal(testdata+"\n"+testdata+"/sub\n"))
+
+	out, err = osexec.Command(bin, "show", "-C", dir, testdata+"/sub.Curry").CombinedOutput()
+	Expect(t, err, Succeed())
+	Expect(t, string(out), Equal("func Curry() func() string\n"))
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/xoctopus/pkgx/pkg/pkgx"
+)
+
+func export(ctx context.Context, fs *flags, args []string, w io.Writer) error {
+	_ = fs.Bool("json", true, "export as json document, the only supported format")
+	opts := pkgx.ExportOptions{}
+	fs.BoolVar(&opts.Dependencies, "deps", false, "export dependencies")
+	fs.BoolVar(&opts.Relative, "relative", false, "trim module dir from source file paths")
+	fs.StringVar(&opts.Indent, "indent", "", "indent of json document")
+
+	u, err := fs.load(ctx, args)
+	if err != nil {
+		return err
+	}
+	if json := fs.Lookup("json").Value.String(); json != "true" {
+		return errors.New("export: only json format is supported")
+	}
+	return u.Export(w, opts)
+}
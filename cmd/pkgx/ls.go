@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+)
+
+func ls(ctx context.Context, fs *flags, args []string, w io.Writer) error {
+	all := fs.Bool("packages", false, "list all loaded packages, includes dependencies")
+	modules := fs.Bool("modules", false, "list modules under entries")
+
+	u, err := fs.load(ctx, args)
+	if err != nil {
+		return err
+	}
+
+	seq := u.Directs
+	switch {
+	case *modules:
+		seq = u.Modules
+	case *all:
+		seq = func(yield func(string) bool) {
+			for path := range u.Packages {
+				if !yield(path) {
+					return
+				}
+			}
+		}
+	}
+
+	for _, v := range slices.Sorted(seq) {
+		if _, err = fmt.Fprintln(w, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
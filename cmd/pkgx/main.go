@@ -0,0 +1,102 @@
+// Command pkgx inspects go packages with the model of pkgx.
+//
+//	pkgx ls     [-C dir] [-work go.work] [-tests] [-packages|-modules] patterns...
+//	pkgx show   [-C dir] [-work go.work] [-tests] pkg.Name
+//	pkgx sum    [-C dir] [-work go.work] [-check|-write] patterns...
+//	pkgx export [-C dir] [-work go.work] [-json] [-deps] [-relative] [-indent str] patterns...
+//
+// with -work, packages are loaded in workspace mode, and all modules used by
+// go.work are loaded if no pattern is given.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/xoctopus/x/contextx"
+
+	"github.com/xoctopus/pkgx/pkg/pkgx"
+)
+
+func main() {
+	if err := run(context.Background(), os.Args[1:], os.Stdout); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, "pkgx:", err)
+		os.Exit(1)
+	}
+}
+
+type command struct {
+	name  string
+	usage string
+	run   func(ctx context.Context, fs *flags, args []string, w io.Writer) error
+}
+
+var commands = []*command{
+	{"ls", "list direct packages, all packages or modules", ls},
+	{"show", "show documents, tags, fields, methods or value of an object", show},
+	{"sum", "check or write go.xsum of modules", sum},
+	{"export", "export packages model as json", export},
+}
+
+var errUsage = errors.New("usage: pkgx <ls|show|sum|export> [flags] args...")
+
+func run(ctx context.Context, args []string, w io.Writer) (err error) {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	for _, c := range commands {
+		if c.name != args[0] {
+			continue
+		}
+
+		fs := &flags{FlagSet: flag.NewFlagSet(c.name, flag.ContinueOnError)}
+		fs.SetOutput(w)
+		fs.Usage = func() {
+			_, _ = fmt.Fprintf(w, "pkgx %s: %s\n", c.name, c.usage)
+			fs.PrintDefaults()
+		}
+		fs.StringVar(&fs.workdir, "C", "", "change to dir before loading packages")
+		fs.BoolVar(&fs.tests, "tests", false, "load test packages")
+		fs.StringVar(&fs.work, "work", "", "load packages in workspace of go.work")
+
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("%v", r)
+			}
+		}()
+		return c.run(ctx, fs, args[1:], w)
+	}
+	return errUsage
+}
+
+type flags struct {
+	*flag.FlagSet
+	workdir string
+	work    string
+	tests   bool
+}
+
+// load parses flags and loads packages of remaining arguments
+func (fs *flags) load(ctx context.Context, args []string) (*pkgx.Packages, error) {
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() == 0 && fs.work == "" {
+		return nil, fmt.Errorf("%s: no patterns", fs.Name())
+	}
+
+	ctx = contextx.Compose(
+		pkgx.CtxWorkdir.Carry(fs.workdir),
+		pkgx.CtxWorkspace.Carry(fs.work),
+		pkgx.CtxLoadTests.Carry(fs.tests),
+	)(ctx)
+	if fs.NArg() == 0 {
+		return pkgx.LoadWorkspace(ctx)
+	}
+	return pkgx.LoadPackages(ctx, fs.Args()...)
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/xoctopus/x/testx"
+	"golang.org/x/tools/txtar"
+
+	"github.com/xoctopus/pkgx/pkg/pkgx"
+	"github.com/xoctopus/pkgx/pkg/pkgxtest"
+)
+
+var (
+	_, _     = os.Setenv("GOWORK", "off"), 0
+	cwd, _   = os.Getwd()
+	dir      = filepath.Join(cwd, "..", "..", "testdata")
+	testdata = "github.com/xoctopus/pkgx/testdata"
+)
+
+func exec(args ...string) (string, error) {
+	b := bytes.NewBuffer(nil)
+	err := run(context.Background(), args, b)
+	return b.String(), err
+}
+
+func TestRun(t *testing.T) {
+	t.Run("Usage", func(t *testing.T) {
+		_, err := exec()
+		Expect(t, err, Equal(errUsage))
+		_, err = exec("unknown")
+		Expect(t, err, Equal(errUsage))
+		_, err = exec("ls", "-C", dir)
+		Expect(t, err, ErrorContains("no patterns"))
+		_, err = exec("ls", "-unknown")
+		Expect(t, err, Failed())
+	})
+
+	t.Run("Ls", func(t *testing.T) {
+		out, err := exec("ls", "-C", dir, "./...")
+		Expect(t, err, Succeed())
+		Expect(t, out, Equal(testdata+"\n"+testdata+"/sub\n"))
+
+		out, err = exec("ls", "-C", dir, "-modules", ".")
+		Expect(t, err, Succeed())
+		Expect(t, out, Equal(testdata+"\n"))
+
+		out, err = exec("ls", "-C", dir, "-packages", "./sub")
+		Expect(t, err, Succeed())
+		Expect(t, out, ContainsSubString("\ncontext\n"))
+		Expect(t, out, ContainsSubString("\n"+testdata+"/sub\n"))
+	})
+
+	t.Run("Show", func(t *testing.T) {
+		out, err := exec("show", "-C", dir, testdata+".Structure")
+		Expect(t, err, Succeed())
+		Expect(t, out, Equal(`type Structure struct{name string; fieldX any}
+doc:
+	Structure is a struct type for testing
+	line1
+	line2
+tags:
+	ignore: name
+fields:
+	name string // name comments
+	fieldX any
+methods:
+	(*Structure) Name() string
+	(Structure) String() string
+	(Structure) Value() any
+`))
+
+		out, err = exec("show", "-C", dir, testdata+".IntConstTypeValue3")
+		Expect(t, err, Succeed())
+		Expect(t, out, Equal("const IntConstTypeValue3 IntConstType = 4\ndoc:\n\tcomment 3\n"))
+
+		out, err = exec("show", "-C", dir, testdata+"/sub.Curry")
+		Expect(t, err, Succeed())
+		Expect(t, out, Equal("func Curry() func() string\n"))
+
+		out, err = exec("show", "-C", dir, testdata+".Structure.Name")
+		Expect(t, err, Succeed())
+		Expect(t, out, Equal("func (*Structure) Name() string\n"))
+
+		out, err = exec("show", "-C", dir, testdata+".Structure.name")
+		Expect(t, err, Succeed())
+		Expect(t, out, Equal("field Structure.name string\ndoc:\n\tname comments\n"))
+
+		// last element of package path contains dot
+		root := pkgxtest.Write(t, txtar.Parse([]byte(`
+-- go.mod --
+module example.com/yaml.v3
+
+go 1.22
+-- yaml.go --
+package yaml
+
+// Node is a yaml node
+type Node struct{}
+`)))
+		out, err = exec("show", "-C", root, "example.com/yaml.v3.Node")
+		Expect(t, err, Succeed())
+		Expect(t, out, Equal("type Node struct{}\ndoc:\n\tNode is a yaml node\n"))
+
+		_, err = exec("show", "-C", dir, testdata+".NotFound")
+		Expect(t, err, ErrorContains("not found"))
+		_, err = exec("show", "-C", dir, "fmt")
+		Expect(t, err, ErrorContains("invalid qualified name"))
+		_, err = exec("show", "-C", dir)
+		Expect(t, err, ErrorContains("expect one argument"))
+		_, err = exec("show", "-C", dir, "not/exists.Name")
+		Expect(t, err, Failed())
+	})
+
+	t.Run("Sum", func(t *testing.T) {
+		tmp := t.TempDir()
+		Expect(t, os.CopyFS(tmp, os.DirFS(dir)), Succeed())
+
+		_, err := exec("sum", "-C", tmp, "-write", "./...")
+		Expect(t, err, Succeed())
+		out, err := exec("sum", "-C", tmp, "-check", "./...")
+		Expect(t, err, Succeed())
+		Expect(t, out, Equal(""))
+
+		Expect(t, os.WriteFile(filepath.Join(tmp, pkgx.SumFilename), nil, os.ModePerm), Succeed())
+		out, err = exec("sum", "-C", tmp, "-check", "./...")
+		Expect(t, err, ErrorContains("out of date"))
+		Expect(t, out, ContainsSubString(testdata+"/sub recorded"))
+
+		out, err = exec("sum", "-C", tmp, "./sub")
+		Expect(t, err, Succeed())
+		Expect(t, out, HavePrefix(testdata+"/sub h1:"))
+	})
+
+	t.Run("Workspace", func(t *testing.T) {
+		// -mod=mod is not allowed in workspace mode
+		t.Setenv("GOFLAGS", "")
+		root := pkgxtest.Write(t, txtar.Parse([]byte(`
+-- go.work --
+go 1.22
+
+use (
+	./a
+	./b
+	./c
+)
+-- a/go.mod --
+module example.com/a
+
+go 1.22
+-- a/a.go --
+package a
+-- b/go.mod --
+module example.com/b
+
+go 1.22
+-- b/b.go --
+package b
+
+import _ "example.com/a"
+-- c/go.mod --
+module example.com/c
+
+go 1.22
+-- c/c.go --
+package c
+`)))
+
+		out, err := exec("ls", "-C", root, "-work", "go.work", "-modules")
+		Expect(t, err, Succeed())
+		Expect(t, out, Equal("example.com/a\nexample.com/b\nexample.com/c\n"))
+
+		out, err = exec("ls", "-C", root, "-work", "go.work", "example.com/b")
+		Expect(t, err, Succeed())
+		Expect(t, out, Equal("example.com/a\nexample.com/b\n"))
+
+		// only workspace modules of loaded packages are listed
+		out, err = exec("sum", "-C", root, "-work", "go.work", "example.com/b")
+		Expect(t, err, Succeed())
+		Expect(t, out, HavePrefix("example.com/a h1:"))
+		Expect(t, out, ContainsSubString("\nexample.com/b h1:"))
+		Expect(t, out, Not(ContainsSubString("example.com/c")))
+
+		_, err = exec("sum", "-C", root, "-work", "go.work", "-write")
+		Expect(t, err, Succeed())
+		for _, m := range []string{"a", "b", "c"} {
+			_, err = os.Stat(filepath.Join(root, m, pkgx.SumFilename))
+			Expect(t, err, Succeed())
+		}
+	})
+
+	t.Run("Export", func(t *testing.T) {
+		out, err := exec("export", "-C", dir, "-json", "-relative", "./sub")
+		Expect(t, err, Succeed())
+
+		s := &pkgx.Snapshot{}
+		Expect(t, json.Unmarshal([]byte(out), s), Succeed())
+		Expect(t, s.Package(testdata+"/sub").Dir, Equal("sub"))
+
+		_, err = exec("export", "-C", dir, "-json=false", "./sub")
+		Expect(t, err, ErrorContains("only json"))
+	})
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go/types"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	internal "github.com/xoctopus/pkgx/internal/pkgx"
+	"github.com/xoctopus/pkgx/pkg/pkgx"
+)
+
+func show(ctx context.Context, fs *flags, args []string, w io.Writer) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("show: expect one argument as `pkg.Name`")
+	}
+
+	e, err := lookup(ctx, fs, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	b := &strings.Builder{}
+	qualifier := types.RelativeTo(e.Package.Unwrap())
+	switch {
+	case e.Field != nil:
+		fmt.Fprintf(b, "field %s %s\n", e.Name(), types.TypeString(e.Field.Type(), qualifier))
+		writeDoc(b, e.Doc())
+	case e.TypeName != nil && e.Function != nil:
+		fmt.Fprintf(b, "func %s\n", method(e.TypeName, e.Function, qualifier))
+		writeDoc(b, e.Doc())
+	case e.TypeName != nil:
+		t := e.TypeName
+		fmt.Fprintf(b, "type %s %s\n", t.Name(), types.TypeString(t.Type().Underlying(), qualifier))
+		writeDoc(b, t.Doc())
+		if st, ok := t.Type().Underlying().(*types.Struct); ok && st.NumFields() > 0 {
+			b.WriteString("fields:\n")
+			for f := range st.Fields() {
+				fmt.Fprintf(b, "\t%s %s", f.Name(), types.TypeString(f.Type(), qualifier))
+				if d := t.GetFieldDocByName(f.Name()); len(d) > 0 {
+					fmt.Fprintf(b, " // %s", strings.Join(d, " "))
+				}
+				b.WriteString("\n")
+			}
+		}
+		if methods := t.Methods().Keys(); len(methods) > 0 {
+			slices.Sort(methods)
+			b.WriteString("methods:\n")
+			for _, m := range methods {
+				fmt.Fprintf(b, "\t%s\n", method(t, t.Method(m), qualifier))
+			}
+		}
+	case e.Constant != nil:
+		c := e.Constant
+		fmt.Fprintf(b, "const %s %s = %s\n", c.Name(), types.TypeString(c.Type(), qualifier), c.Value().ExactString())
+		writeDoc(b, c.Doc())
+	case e.Function != nil:
+		f := e.Function
+		sig := types.TypeString(f.Type(), qualifier)
+		fmt.Fprintf(b, "func %s%s\n", f.Name(), strings.TrimPrefix(sig, "func"))
+		writeDoc(b, f.Doc())
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// lookup loads package of qualified name and resolves element by it. the
+// package path may contain dots after last slash, like `gopkg.in/yaml.v3`, so
+// each dot is tried as separator of package path until the element is found.
+func lookup(ctx context.Context, fs *flags, qualified string) (*pkgx.Element, error) {
+	slash := strings.LastIndex(qualified, "/")
+	err := fmt.Errorf("show: invalid qualified name: %s", qualified)
+	for i := slash + 1; i < len(qualified)-1; i++ {
+		if qualified[i] != '.' {
+			continue
+		}
+		path := qualified[:i]
+		u, e := fs.load(ctx, []string{path})
+		if e != nil {
+			err = e
+			continue
+		}
+		if elem := u.Lookup(qualified); elem != nil {
+			return elem, nil
+		}
+		err = fmt.Errorf("show: %s not found in %s", qualified[i+1:], path)
+	}
+	return nil, err
+}
+
+// method formats method f of typename t like `(*T) Name(args) results`
+func method(t *pkgx.TypeName, f *pkgx.Function, qualifier types.Qualifier) string {
+	recv := t.Name()
+	if f.PtrRecv() {
+		recv = "*" + recv
+	}
+	sig := types.TypeString(f.Type(), qualifier)
+	return fmt.Sprintf("(%s) %s%s", recv, f.Name(), strings.TrimPrefix(sig, "func"))
+}
+
+func writeDoc(b *strings.Builder, doc []string) {
+	desc, tags := internal.ParseLines(doc)
+	if len(desc) > 0 {
+		b.WriteString("doc:\n")
+		for _, line := range desc {
+			fmt.Fprintf(b, "\t%s\n", line)
+		}
+	}
+	if len(tags) > 0 {
+		b.WriteString("tags:\n")
+		for _, k := range slices.Sorted(maps.Keys(tags)) {
+			fmt.Fprintf(b, "\t%s: %s\n", k, strings.Join(tags[k], ","))
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+func sum(ctx context.Context, fs *flags, args []string, w io.Writer) error {
+	check := fs.Bool("check", false, "check if go.xsum is up to date")
+	write := fs.Bool("write", false, "write go.xsum to module dir")
+
+	u, err := fs.load(ctx, args)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case *write:
+		return u.SaveSums()
+	case *check:
+		drifts := u.CheckSums()
+		for _, d := range drifts {
+			if _, err = fmt.Fprintf(w, "%s: %s recorded %q current %q\n", d.Module, d.Package, d.Recorded, d.Current); err != nil {
+				return err
+			}
+		}
+		if len(drifts) > 0 {
+			return errors.New("sum: go.xsum is out of date")
+		}
+		return nil
+	default:
+		for _, module := range slices.Sorted(u.Modules) {
+			s := u.ModuleSum(module)
+			if s == nil {
+				continue
+			}
+			for _, id := range s.Packages() {
+				if _, err = fmt.Fprintf(w, "%s %s\n", id, s.Hash(id)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+}
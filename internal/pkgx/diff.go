@@ -0,0 +1,195 @@
+package pkgx
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Diff returns unified diff of old and new text with 3 lines context, it
+// returns empty if texts are equal
+func Diff(oldName, old, newName, new string) string {
+	if old == new {
+		return ""
+	}
+
+	a, b := splitLines(old), splitLines(new)
+	ops := diffLines(a, b)
+
+	out := &strings.Builder{}
+	_, _ = fmt.Fprintf(out, "--- %s\n+++ %s\n", oldName, newName)
+
+	const context = 3
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		// hunk [start, end) covers changes and their surrounding context
+		start := max(i-context, 0)
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			n := 0
+			for end+n < len(ops) && ops[end+n].kind == ' ' {
+				n++
+			}
+			if end+n == len(ops) || n > 2*context {
+				end = min(end+context, len(ops))
+				break
+			}
+			end += n
+		}
+
+		ai, bi, an, bn := ops[start].a, ops[start].b, 0, 0
+		for _, op := range ops[start:end] {
+			switch op.kind {
+			case ' ':
+				an, bn = an+1, bn+1
+			case '-':
+				an++
+			case '+':
+				bn++
+			}
+		}
+		if an == 0 {
+			ai--
+		}
+		if bn == 0 {
+			bi--
+		}
+		_, _ = fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(ai, an), hunkRange(bi, bn))
+		for _, op := range ops[start:end] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = end
+	}
+	return out.String()
+}
+
+// hunkRange formats line range of hunk as `diff -u`, count is omitted if it is
+// single line
+func hunkRange(i, n int) string {
+	if n == 1 {
+		return strconv.Itoa(i + 1)
+	}
+	return fmt.Sprintf("%d,%d", i+1, n)
+}
+
+type diffOp struct {
+	kind byte // ' ' for equal, '-' for deletion and '+' for insertion
+	line string
+	a, b int // line index in old and new
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes edit script of a to b by longest common subsequence. the
+// common prefix and suffix are trimmed before computing
+func diffLines(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for i := range prefix {
+		ops = append(ops, diffOp{' ', a[i], i, i})
+	}
+	ops = lcsOps(ops, a[prefix:len(a)-suffix], b[prefix:len(b)-suffix], prefix, prefix)
+	for k := range suffix {
+		ai, bi := len(a)-suffix+k, len(b)-suffix+k
+		ops = append(ops, diffOp{' ', a[ai], ai, bi})
+	}
+	return ops
+}
+
+// lcsOps appends edit script of a to b to ops by Hirschberg's algorithm, which
+// takes linear space of len(b). ai and bi are offsets of a and b in texts
+func lcsOps(ops []diffOp, a, b []string, ai, bi int) []diffOp {
+	switch {
+	case len(a) == 0:
+		for j := range b {
+			ops = append(ops, diffOp{'+', b[j], ai, bi + j})
+		}
+		return ops
+	case len(b) == 0:
+		for i := range a {
+			ops = append(ops, diffOp{'-', a[i], ai + i, bi})
+		}
+		return ops
+	case len(a) == 1:
+		j := slices.Index(b, a[0])
+		if j < 0 {
+			ops = append(ops, diffOp{'-', a[0], ai, bi})
+			return lcsOps(ops, nil, b, ai+1, bi)
+		}
+		ops = lcsOps(ops, nil, b[:j], ai, bi)
+		ops = append(ops, diffOp{' ', a[0], ai, bi + j})
+		return lcsOps(ops, nil, b[j+1:], ai+1, bi+j+1)
+	}
+
+	// split b at k where lcs of a[:mid] and b[:k] plus lcs of a[mid:] and
+	// b[k:] is the longest
+	mid := len(a) / 2
+	forward, backward := lcsLengths(a[:mid], b, false), lcsLengths(a[mid:], b, true)
+	k := 0
+	for j := range forward {
+		if forward[j]+backward[j] > forward[k]+backward[k] {
+			k = j
+		}
+	}
+	ops = lcsOps(ops, a[:mid], b[:k], ai, bi)
+	return lcsOps(ops, a[mid:], b[k:], ai+mid, bi+k)
+}
+
+// lcsLengths returns lengths of lcs of a and each prefix b[:j], or each suffix
+// b[j:] if reverse, j is in [0, len(b)]
+func lcsLengths(a, b []string, reverse bool) []int {
+	prev, curr := make([]int, len(b)+1), make([]int, len(b)+1)
+	for n := range a {
+		if !reverse {
+			for j := 1; j <= len(b); j++ {
+				if a[n] == b[j-1] {
+					curr[j] = prev[j-1] + 1
+				} else {
+					curr[j] = max(prev[j], curr[j-1])
+				}
+			}
+		} else {
+			i := len(a) - 1 - n
+			for j := len(b) - 1; j >= 0; j-- {
+				if a[i] == b[j] {
+					curr[j] = prev[j+1] + 1
+				} else {
+					curr[j] = max(prev[j], curr[j+1])
+				}
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return prev
+}
@@ -0,0 +1,299 @@
+package pkgx_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/xoctopus/x/testx"
+
+	"github.com/xoctopus/pkgx/internal/pkgx"
+)
+
+func ExampleDiff() {
+	old := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+	new := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn"
+	fmt.Print(pkgx.Diff("a/file.go", old, "b/file.go", new))
+
+	// Output:
+	// --- a/file.go
+	// +++ b/file.go
+	// @@ -1,5 +1,5 @@
+	//  a
+	// -b
+	// +B
+	//  c
+	//  d
+	//  e
+	// @@ -11,3 +11,4 @@
+	//  k
+	//  l
+	//  m
+	// +n
+	// \ No newline at end of file
+}
+
+func TestDiff(t *testing.T) {
+	Expect(t, pkgx.Diff("a", "x\n", "b", "x\n"), Equal(""))
+	Expect(t, pkgx.Diff("a", "", "b", "x\ny\n"), Equal("--- a\n+++ b\n@@ -0,0 +1,2 @@\n+x\n+y\n"))
+	Expect(t, pkgx.Diff("a", "x\ny\n", "b", ""), Equal("--- a\n+++ b\n@@ -1,2 +0,0 @@\n-x\n-y\n"))
+
+	if _, err := exec.LookPath("patch"); err != nil {
+		t.Skip("patch is not found")
+	}
+	cases := [][2]string{
+		{"1\n2\n3\n4\n5\n6\n7\n8\n9\n", "0\n1\n2\n4\n5\n6\n7\n8\n9\n10\n"},
+		{"a\nb\nc\n", "c\nb\na\n"},
+		{strings.Repeat("x\n", 20) + "y\n", "y\n" + strings.Repeat("x\n", 20)},
+		{"a\nb\nc\nd\ne\nf\ng\nh\n", "a\nb\nX\nd\ne\nf\ng\nY\n"},
+		{"a\nb\nc\nb\na\n", "b\na\nc\na\nb\nx\n"},
+		large(),
+	}
+	for _, c := range cases {
+		dir := t.TempDir()
+		filename := filepath.Join(dir, "file")
+		Expect(t, os.WriteFile(filename, []byte(c[0]), 0o644), Succeed())
+		diff := pkgx.Diff("a/file", c[0], "b/file", c[1])
+		cmd := exec.Command("patch", "-s", filename)
+		cmd.Stdin = strings.NewReader(diff)
+		out, err := cmd.CombinedOutput()
+		Expect(t, err, Succeed())
+		Expect(t, string(out), Equal(""))
+		patched, _ := os.ReadFile(filename)
+		Expect(t, string(patched), Equal(c[1]))
+	}
+}
+
+// TestDiff_Unified checks hunk boundaries, context and end of file cases. the
+// expected hunks are produced by `diff -u`, and they are compared with it again
+// if diff is found.
+func TestDiff_Unified(t *testing.T) {
+	cases := []struct {
+		name string
+		old  string
+		new  string
+		diff string
+	}{
+		{
+			name: "OneLine",
+			old:  "a\n",
+			new:  "b\n",
+			diff: `@@ -1 +1 @@
+-a
++b
+`,
+		},
+		{
+			name: "MergedHunks",
+			old:  lines(20),
+			new:  replace(lines(20), 4, 11),
+			diff: `@@ -1,14 +1,14 @@
+ l1
+ l2
+ l3
+-l4
++x4
+ l5
+ l6
+ l7
+ l8
+ l9
+ l10
+-l11
++x11
+ l12
+ l13
+ l14
+`,
+		},
+		{
+			name: "SplitHunks",
+			old:  lines(20),
+			new:  replace(lines(20), 4, 12),
+			diff: `@@ -1,7 +1,7 @@
+ l1
+ l2
+ l3
+-l4
++x4
+ l5
+ l6
+ l7
+@@ -9,7 +9,7 @@
+ l9
+ l10
+ l11
+-l12
++x12
+ l13
+ l14
+ l15
+`,
+		},
+		{
+			name: "ContextAtStart",
+			old:  lines(10),
+			new:  replace(lines(10), 1),
+			diff: `@@ -1,4 +1,4 @@
+-l1
++x1
+ l2
+ l3
+ l4
+`,
+		},
+		{
+			name: "ContextAtEnd",
+			old:  lines(10),
+			new:  replace(lines(10), 10),
+			diff: `@@ -7,4 +7,4 @@
+ l7
+ l8
+ l9
+-l10
++x10
+`,
+		},
+		{
+			name: "InsertAtStart",
+			old:  lines(5),
+			new:  "x\n" + lines(5),
+			diff: `@@ -1,3 +1,4 @@
++x
+ l1
+ l2
+ l3
+`,
+		},
+		{
+			name: "AppendAtEnd",
+			old:  lines(5),
+			new:  lines(5) + "x\n",
+			diff: `@@ -3,3 +3,4 @@
+ l3
+ l4
+ l5
++x
+`,
+		},
+		{
+			name: "NoNewlineOld",
+			old:  "a\nb\nc",
+			new:  "a\nb\nc\n",
+			diff: `@@ -1,3 +1,3 @@
+ a
+ b
+-c
+\ No newline at end of file
++c
+`,
+		},
+		{
+			name: "NoNewlineNew",
+			old:  "a\nb\nc\n",
+			new:  "a\nb\nc",
+			diff: `@@ -1,3 +1,3 @@
+ a
+ b
+-c
++c
+\ No newline at end of file
+`,
+		},
+		{
+			name: "NoNewlineBoth",
+			old:  "a\nb\nc",
+			new:  "a\nb\nd",
+			diff: `@@ -1,3 +1,3 @@
+ a
+ b
+-c
+\ No newline at end of file
++d
+\ No newline at end of file
+`,
+		},
+		{
+			name: "NoNewlineOutOfContext",
+			old:  lines(8) + "end",
+			new:  replace(lines(8), 1) + "end",
+			diff: `@@ -1,4 +1,4 @@
+-l1
++x1
+ l2
+ l3
+ l4
+`,
+		},
+		{
+			name: "NoNewlineInContext",
+			old:  "a\nb\nc\nd",
+			new:  "x\nb\nc\nd",
+			diff: `@@ -1,4 +1,4 @@
+-a
++x
+ b
+ c
+ d
+\ No newline at end of file
+`,
+		},
+	}
+
+	_, err := exec.LookPath("diff")
+	gnu := err == nil
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			Expect(t, pkgx.Diff("a", c.old, "b", c.new), Equal("--- a\n+++ b\n"+c.diff))
+			if !gnu {
+				return
+			}
+			dir := t.TempDir()
+			Expect(t, os.WriteFile(filepath.Join(dir, "a"), []byte(c.old), 0o644), Succeed())
+			Expect(t, os.WriteFile(filepath.Join(dir, "b"), []byte(c.new), 0o644), Succeed())
+			cmd := exec.Command("diff", "-u", "a", "b")
+			cmd.Dir = dir
+			out, _ := cmd.Output()
+			// skip header lines with timestamps
+			Expect(t, strings.SplitN(string(out), "\n", 3)[2], Equal(c.diff))
+		})
+	}
+}
+
+// lines returns text of n lines from `l1` to `ln`
+func lines(n int) string {
+	b := &strings.Builder{}
+	for i := 1; i <= n; i++ {
+		_, _ = fmt.Fprintf(b, "l%d\n", i)
+	}
+	return b.String()
+}
+
+// replace replaces line `li` with `xi` for each i of nums
+func replace(s string, nums ...int) string {
+	for _, i := range nums {
+		s = strings.Replace(s, fmt.Sprintf("l%d\n", i), fmt.Sprintf("x%d\n", i), 1)
+	}
+	return s
+}
+
+// large returns texts of thousands lines with scattered changes
+func large() [2]string {
+	a, b := &strings.Builder{}, &strings.Builder{}
+	for i := range 5000 {
+		_, _ = fmt.Fprintf(a, "line %d\n", i)
+		switch i % 97 {
+		case 0:
+			_, _ = fmt.Fprintf(b, "changed %d\n", i)
+		case 1:
+		case 2:
+			_, _ = fmt.Fprintf(b, "line %d\ninserted %d\n", i, i)
+		default:
+			_, _ = fmt.Fprintf(b, "line %d\n", i)
+		}
+	}
+	return [2]string{a.String(), b.String()}
+}
@@ -29,10 +29,7 @@ func ParseDocument(doc *ast.CommentGroup, comments ...*ast.CommentGroup) *Doc {
 		return docs[i].Pos() < docs[j].Pos()
 	})
 
-	d := &Doc{
-		tags:         make(map[string][]string),
-		CommentGroup: &ast.CommentGroup{List: docs},
-	}
+	d := &Doc{CommentGroup: &ast.CommentGroup{List: docs}}
 
 	text := make([]string, 0, len(docs))
 	for _, c := range docs {
@@ -47,9 +44,28 @@ func ParseDocument(doc *ast.CommentGroup, comments ...*ast.CommentGroup) *Doc {
 		}
 	}
 
-	for _, line := range text {
+	d.desc, d.tags = ParseLines(text)
+	if len(d.desc) == 0 && len(d.tags) == 0 {
+		return &Doc{tags: make(map[string][]string)}
+	}
+
+	for key := range d.tags {
+		d.keys = append(d.keys, key)
+	}
+	sort.Strings(d.keys)
+	return d
+}
+
+// ParseLines splits document lines to description and `+key=value` tags, empty
+// tag values are dropped
+func ParseLines(lines []string) (desc []string, tags map[string][]string) {
+	tags = make(map[string][]string)
+	for _, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
 		if line[0] != '+' {
-			d.desc = append(d.desc, line)
+			desc = append(desc, line)
 			continue
 		}
 		line = line[1:]
@@ -60,26 +76,14 @@ func ParseDocument(doc *ast.CommentGroup, comments ...*ast.CommentGroup) *Doc {
 			k = strings.TrimSpace(before)
 			v = strings.TrimSpace(after)
 		}
-		d.tags[k] = append(d.tags[k], v)
-	}
-	if len(d.desc) == 0 && len(d.tags) == 0 {
-		return &Doc{tags: make(map[string][]string)}
-	}
-	for tag, vals := range d.tags {
-		trimmed := make([]string, 0, len(vals))
-		for _, v := range vals {
-			if v != "" {
-				trimmed = append(trimmed, v)
-			}
+		if _, ok := tags[k]; !ok {
+			tags[k] = make([]string, 0)
+		}
+		if v != "" {
+			tags[k] = append(tags[k], v)
 		}
-		d.tags[tag] = trimmed
-	}
-
-	for key := range d.tags {
-		d.keys = append(d.keys, key)
 	}
-	sort.Strings(d.keys)
-	return d
+	return desc, tags
 }
 
 var DefaultDoc = &Doc{tags: make(map[string][]string), CommentGroup: &ast.CommentGroup{}}
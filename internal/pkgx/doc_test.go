@@ -94,3 +94,19 @@ func TestExtractDocuments(t *testing.T) {
 		"line4",
 	}))
 }
+
+func TestParseLines(t *testing.T) {
+	desc, tags := pkgx.ParseLines([]string{
+		"desc line",
+		"",
+		"+genx:enum",
+		"+gen:db = users",
+		"+gen:db=",
+		"+gen:db=orders",
+	})
+	Expect(t, desc, Equal([]string{"desc line"}))
+	Expect(t, tags, Equal(map[string][]string{
+		"genx:enum": {},
+		"gen:db":    {"users", "orders"},
+	}))
+}
@@ -0,0 +1,29 @@
+package pkgx_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/xoctopus/x/testx"
+
+	"github.com/xoctopus/pkgx/internal/pkgx"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "a", "b.txt")
+
+	Expect(t, pkgx.WriteFileAtomic(filename, []byte("content"), 0o644), Succeed())
+	data, err := os.ReadFile(filename)
+	Expect(t, err, Succeed())
+	Expect(t, string(data), Equal("content"))
+
+	info, _ := os.Stat(filename)
+	Expect(t, info.Mode().Perm(), Equal(os.FileMode(0o644)))
+
+	entries, _ := os.ReadDir(filepath.Dir(filename))
+	Expect(t, entries, HaveLen[[]os.DirEntry](1))
+
+	Expect(t, pkgx.WriteFileAtomic(filepath.Join(filename, "c.txt"), nil, 0o644), Failed())
+}
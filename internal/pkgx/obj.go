@@ -6,6 +6,7 @@ import (
 	"go/types"
 	"iter"
 	"sort"
+	"sync"
 
 	"github.com/xoctopus/x/syncx"
 )
@@ -31,11 +32,18 @@ func NewObject[U Exposer](n ast.Node, i *ast.Ident, obj U, d []string) Object[U]
 	return &object[U]{node: n, id: i, u: obj, doc: d}
 }
 
+// NewLazyObject creates object whose documents are resolved by d at first
+// call of Doc, and the result is cached
+func NewLazyObject[U Exposer](n ast.Node, i *ast.Ident, obj U, d func() []string) Object[U] {
+	return &object[U]{node: n, id: i, u: obj, lazy: sync.OnceValue(d)}
+}
+
 type object[U Exposer] struct {
 	u    U
 	node ast.Node
 	id   *ast.Ident
 	doc  []string
+	lazy func() []string
 }
 
 func (o *object[U]) IsNil() bool {
@@ -62,6 +70,9 @@ func (o *object[U]) Exposer() U {
 }
 
 func (o *object[U]) Doc() []string {
+	if o.lazy != nil {
+		return o.lazy()
+	}
 	return o.doc
 }
 
@@ -112,6 +123,8 @@ type objects[U Exposer, V Object[U]] struct {
 	set   syncx.Map[Node, V]
 	nodes []ast.Node
 	vals  []V
+	// names indexes elements by name, it is built in Init
+	names map[string]V
 }
 
 func (s *objects[U, V]) Init(fileset *token.FileSet) {
@@ -128,10 +141,14 @@ func (s *objects[U, V]) Init(fileset *token.FileSet) {
 		return pi.Filename < pj.Filename
 	})
 
+	s.names = make(map[string]V, len(nodes))
 	for _, node := range nodes {
 		e, _ := s.set.Load(NodeOf(node))
 		s.nodes = append(s.nodes, e.Node())
 		s.vals = append(s.vals, e)
+		if _, ok := s.names[e.Name()]; !ok {
+			s.names[e.Name()] = e
+		}
 	}
 }
 
@@ -151,13 +168,23 @@ func (s *objects[U, V]) Nodes() iter.Seq[ast.Node] {
 
 func (s *objects[U, V]) Add(elems ...V) {
 	for _, e := range elems {
-		if e.IsNil() {
+		if isNil(e) {
 			continue
 		}
 		s.set.LoadOrStore(NodeOf(e.Node()), e)
 	}
 }
 
+// nilable is a non-generic view of Object for nil check
+type nilable interface{ IsNil() bool }
+
+// isNil reports if v is nil through nilable. calling IsNil of type parameter
+// directly is dropped as unreachable by linker, which crashes binaries not
+// calling IsNil elsewhere.
+func isNil(v nilable) bool {
+	return v.IsNil()
+}
+
 func (s *objects[U, V]) ExposerOf(node ast.Node) U {
 	if u, ok := s.set.Load(NodeOf(node)); ok {
 		return u.Exposer()
@@ -191,6 +218,9 @@ func (s *objects[U, V]) Elements() iter.Seq[V] {
 }
 
 func (s *objects[U, V]) ElementByName(name string) (e V) {
+	if s.names != nil {
+		return s.names[name]
+	}
 	s.set.Range(func(_ Node, v V) bool {
 		if v.Name() == name {
 			e = v
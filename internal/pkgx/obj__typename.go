@@ -2,6 +2,7 @@ package pkgx
 
 import (
 	"go/types"
+	"sync"
 
 	"github.com/xoctopus/x/syncx"
 )
@@ -17,6 +18,7 @@ type TypeName struct {
 	Object[*types.TypeName]
 	methods syncx.Map[string, *Function]
 	docs    map[string][]string
+	lazy    func() map[string][]string
 }
 
 func (t *TypeName) Methods() syncx.Map[string, *Function] {
@@ -38,6 +40,15 @@ func (t *TypeName) SetFieldDocs(docs map[string][]string) {
 	t.docs = docs
 }
 
+// SetFieldDocsFunc sets resolver of field documents, it is called at first
+// query of field documents and the result is cached
+func (t *TypeName) SetFieldDocsFunc(f func() map[string][]string) {
+	t.lazy = sync.OnceValue(f)
+}
+
 func (t *TypeName) GetFieldDocByName(name string) []string {
+	if t.lazy != nil {
+		return t.lazy()[name]
+	}
 	return t.docs[name]
 }
@@ -33,6 +33,26 @@ func TestNewObject(t *testing.T) {
 		Expect(t, o.Type(), BeNil[types.Type]())
 		Expect(t, o.TypeName(), HaveLen[string](0))
 	})
+	t.Run("LazyDoc", func(t *testing.T) {
+		calls := 0
+		o := pkgi.NewLazyObject[*types.TypeName](nil, nil, nil, func() []string {
+			calls++
+			return []string{"doc"}
+		})
+		Expect(t, calls, Equal(0))
+		Expect(t, o.Doc(), Equal([]string{"doc"}))
+		Expect(t, o.Doc(), Equal([]string{"doc"}))
+		Expect(t, calls, Equal(1))
+
+		n := pkgi.NewTypeName(o)
+		n.SetFieldDocsFunc(func() map[string][]string {
+			calls++
+			return map[string][]string{"f": {"field doc"}}
+		})
+		Expect(t, n.GetFieldDocByName("f"), Equal([]string{"field doc"}))
+		Expect(t, n.GetFieldDocByName("g"), HaveLen[[]string](0))
+		Expect(t, calls, Equal(2))
+	})
 }
 
 func TestObject(t *testing.T) {
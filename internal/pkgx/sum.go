@@ -2,10 +2,12 @@ package pkgx
 
 import (
 	"bytes"
+	"io"
 	"maps"
 	"os"
 	"path/filepath"
 	"slices"
+	"sync"
 
 	"golang.org/x/mod/sumdb/dirhash"
 	gopkg "golang.org/x/tools/go/packages"
@@ -20,6 +22,7 @@ func LoadSumFile(m *gopkg.Module) Sum {
 	s := &sum{
 		dir:    m.Dir,
 		hashes: make(map[string]string),
+		dirs:   make(map[string]string),
 	}
 
 	data, err := os.ReadFile(filepath.Join(m.Dir, SumFilename))
@@ -47,37 +50,76 @@ type Sum interface {
 	Save() error
 	// Hash returns hash of package by package path
 	Hash(string) string
+	// Packages returns sorted package paths in sum
+	Packages() []string
+	// Rehash recomputes hashes of added packages from current files
+	Rehash()
 }
 
 func NewSum(dir string) Sum {
-	return &sum{dir: dir, hashes: make(map[string]string)}
+	return &sum{
+		dir:    dir,
+		hashes: make(map[string]string),
+		dirs:   make(map[string]string),
+	}
 }
 
 type sum struct {
+	mu sync.RWMutex
 	// dir module source dir
 	dir string
 	// hashes of packages
 	hashes map[string]string
+	// dirs of added packages
+	dirs map[string]string
 }
 
 func (s *sum) Dir() string { return s.dir }
 
 func (s *sum) Add(p *gopkg.Package) {
-	if _, ok := s.hashes[p.ID]; !ok {
-		h, _ := dirhash.HashDir(p.Dir, "", dirhash.Hash1)
-		s.hashes[p.ID] = h
+	s.mu.RLock()
+	_, ok := s.hashes[p.ID]
+	s.mu.RUnlock()
+	if ok {
+		return
 	}
+
+	hash := HashDir(p.Dir)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok = s.hashes[p.ID]; !ok {
+		s.hashes[p.ID] = hash
+		s.dirs[p.ID] = p.Dir
+	}
+}
+
+func (s *sum) Rehash() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, dir := range s.dirs {
+		s.hashes[id] = HashDir(dir)
+	}
+}
+
+func (s *sum) Hash(path string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hashes[path]
 }
 
-func (s *sum) Hash(path string) string { return s.hashes[path] }
+func (s *sum) Packages() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Sorted(maps.Keys(s.hashes))
+}
 
 func (s *sum) Save() error {
 	b := bytes.NewBuffer(nil)
 
-	for _, path := range slices.Sorted(maps.Keys(s.hashes)) {
+	for _, path := range s.Packages() {
 		b.WriteString(path)
 		b.WriteString(" ")
-		b.WriteString(s.hashes[path])
+		b.WriteString(s.Hash(path))
 		b.WriteString("\n")
 	}
 
@@ -93,3 +135,30 @@ func (s *sum) Save() error {
 	_, err = f.Write(b.Bytes())
 	return err
 }
+
+// HashDir returns hash of files under dir, sum files are excluded so that the
+// hash is stable after saving. it returns empty if failed
+func HashDir(dir string) string {
+	files, err := dirhash.DirFiles(dir, "")
+	if err != nil {
+		return ""
+	}
+	files = slices.DeleteFunc(files, func(name string) bool {
+		return filepath.Base(name) == SumFilename
+	})
+	return HashFiles(dir, files...)
+}
+
+// HashFiles returns hash of named files under dir, files not existed are skipped
+func HashFiles(dir string, names ...string) string {
+	files := make([]string, 0, len(names))
+	for _, name := range names {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			files = append(files, name)
+		}
+	}
+	h, _ := dirhash.Hash1(files, func(name string) (io.ReadCloser, error) {
+		return os.Open(filepath.Join(dir, name))
+	})
+	return h
+}
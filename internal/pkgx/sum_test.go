@@ -72,6 +72,11 @@ func TestLoadSumFile(t *testing.T) {
 		sum.Add(sub)
 		h = sum.Hash(sub.ID)
 		Expect(t, h, NotEqual(""))
+
+		Expect(t, sum.Packages(), Equal([]string{testdata.ID, sub.ID}))
+
+		sum.Rehash()
+		Expect(t, sum.Hash(sub.ID), Equal(h))
 	})
 
 	t.Run("SaveAndLoad", func(t *testing.T) {
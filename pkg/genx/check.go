@@ -0,0 +1,92 @@
+package genx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	internal "github.com/xoctopus/pkgx/internal/pkgx"
+	"github.com/xoctopus/pkgx/pkg/pkgx"
+)
+
+// DriftError reports a file on disk differs from the one generated in memory
+type DriftError struct {
+	Package   string
+	Generator string
+	Filename  string
+	Diff      string
+}
+
+func (e *DriftError) Error() string {
+	return fmt.Sprintf("%s: %s: %s is out of date\n%s", e.Package, e.Generator, e.Filename, e.Diff)
+}
+
+// Check loads packages matched by patterns, regenerates them in memory and
+// compares outputs with files on disk. each drifted file is reported as a
+// *DriftError, includes generated file which has no output any more. for
+// modules which have go.xsum, package hashes are verified too.
+func (r *Runner) Check(ctx context.Context, patterns ...string) error {
+	u, err := pkgx.LoadPackages(ctx, patterns...)
+	if err != nil {
+		return err
+	}
+	outputs, err := r.Generate(ctx, u)
+	if err != nil {
+		return err
+	}
+
+	// stale files are expected to be removed, so they are compared with empty
+	// outputs
+	outputs = append(outputs, r.stale(u, outputs)...)
+
+	errs := make([]error, 0)
+	for _, o := range outputs {
+		existed, _ := os.ReadFile(o.Filename)
+		if diff := internal.Diff(o.Filename, string(existed), o.Filename+" (generated)", string(o.Code)); diff != "" {
+			errs = append(errs, &DriftError{
+				Package:   o.Package,
+				Generator: o.Generator,
+				Filename:  o.Filename,
+				Diff:      diff,
+			})
+		}
+	}
+
+	for _, d := range u.CheckSums() {
+		filename := filepath.Join(u.ModuleSum(d.Module).Dir(), pkgx.SumFilename)
+		if !exists(filename) {
+			continue
+		}
+		errs = append(errs, &DriftError{
+			Package:   d.Package,
+			Generator: pkgx.SumFilename,
+			Filename:  filename,
+			Diff: internal.Diff(
+				filename, d.Package+" "+d.Recorded+"\n",
+				filename+" (current)", d.Package+" "+d.Current+"\n",
+			),
+		})
+	}
+	return errors.Join(errs...)
+}
+
+// refreshSums rewrites go.xsum of modules which already have one
+func refreshSums(u *pkgx.Packages) error {
+	u.RehashSums()
+
+	errs := make([]error, 0)
+	for _, module := range slices.Sorted(u.Modules) {
+		if s := u.ModuleSum(module); s != nil && exists(filepath.Join(s.Dir(), pkgx.SumFilename)) {
+			errs = append(errs, s.Save())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func exists(filename string) bool {
+	_, err := os.Stat(filename)
+	return err == nil
+}
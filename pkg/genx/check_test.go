@@ -0,0 +1,138 @@
+package genx_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/xoctopus/x/testx"
+	"golang.org/x/tools/txtar"
+
+	. "github.com/xoctopus/pkgx/pkg/genx"
+	"github.com/xoctopus/pkgx/pkg/pkgx"
+	"github.com/xoctopus/pkgx/pkg/pkgxtest"
+)
+
+func drifts(err error) []*DriftError {
+	var ds []*DriftError
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range joined.Unwrap() {
+			if d := (*DriftError)(nil); errors.As(e, &d) {
+				ds = append(ds, d)
+			}
+		}
+	}
+	return ds
+}
+
+func TestRunner_Check(t *testing.T) {
+	dir, u := fixture(t)
+	ctx := pkgx.CtxWorkdir.With(context.Background(), dir)
+	r := NewRunner(tagger{}, namer{})
+	// go.xsum is checked only if it exists
+	Expect(t, u.SaveSums(), Succeed())
+
+	t.Run("NotGenerated", func(t *testing.T) {
+		ds := drifts(r.Check(ctx, "./..."))
+		Expect(t, ds, HaveLen[[]*DriftError](2))
+		Expect(t, ds[0].Package, Equal(testdata))
+		Expect(t, ds[0].Generator, Equal("tagger"))
+		Expect(t, ds[0].Diff, ContainsSubString("+func (TypeA) Tag1() string { return \"TypeA\" }"))
+		Expect(t, ds[1].Generator, Equal("pkg-namer"))
+	})
+
+	_, err := r.Run(ctx, "./...")
+	Expect(t, err, Succeed())
+	Expect(t, r.Check(ctx, "./..."), Succeed())
+
+	t.Run("Modified", func(t *testing.T) {
+		filename := filepath.Join(dir, Filename("pkg-namer"))
+		data, err := os.ReadFile(filename)
+		Expect(t, err, Succeed())
+		defer func() { _ = os.WriteFile(filename, data, 0o644) }()
+		Expect(t, os.WriteFile(filename, append(data, "// modified\n"...), 0o644), Succeed())
+
+		err = r.Check(ctx, "./...")
+		ds := drifts(err)
+		Expect(t, ds, HaveLen[[]*DriftError](2))
+		Expect(t, ds[0].Filename, Equal(filename))
+		Expect(t, ds[0].Diff, ContainsSubString("-// modified\n"))
+		Expect(t, err.Error(), ContainsSubString(testdata+": pkg-namer: "+filename+" is out of date\n"))
+		// package files changed, go.xsum drifted as well
+		Expect(t, ds[1].Package, Equal(testdata))
+		Expect(t, ds[1].Generator, Equal(pkgx.SumFilename))
+	})
+
+	t.Run("TriggerRemoved", func(t *testing.T) {
+		source := filepath.Join(dir, "documents.go")
+		data, err := os.ReadFile(source)
+		Expect(t, err, Succeed())
+		defer func() {
+			_ = os.WriteFile(source, data, 0o644)
+			_, _ = r.Run(ctx, "./...")
+		}()
+		Expect(t, os.WriteFile(source, []byte(strings.ReplaceAll(string(data), "+tag1=", "tag1=")), 0o644), Succeed())
+
+		filename := filepath.Join(dir, Filename("tagger"))
+		ds := drifts(r.Check(ctx, "./..."))
+		Expect(t, ds, HaveLen[[]*DriftError](2))
+		Expect(t, ds[0].Package, Equal(testdata))
+		Expect(t, ds[0].Generator, Equal("tagger"))
+		Expect(t, ds[0].Filename, Equal(filename))
+		Expect(t, ds[0].Diff, ContainsSubString("-func (TypeA) Tag1() string { return \"TypeA\" }"))
+		Expect(t, ds[1].Generator, Equal(pkgx.SumFilename))
+
+		outputs, err := r.Run(ctx, "./...")
+		Expect(t, err, Succeed())
+		Expect(t, outputs, HaveLen[[]*Output](1))
+		_, err = os.Stat(filename)
+		Expect(t, os.IsNotExist(err), BeTrue())
+		Expect(t, r.Check(ctx, "./..."), Succeed())
+	})
+
+	t.Run("Exec", func(t *testing.T) {
+		Expect(t, Exec(context.Background(), []string{"-C", dir, "-check"}, tagger{}, namer{}), Succeed())
+		Expect(t, Exec(context.Background(), []string{"-C", dir}, tagger{}, namer{}), Succeed())
+		Expect(t, Exec(context.Background(), []string{"-unknown"}), Failed())
+	})
+
+	t.Run("Workspace", func(t *testing.T) {
+		// -mod=mod is not allowed in workspace mode
+		t.Setenv("GOFLAGS", "")
+		root := pkgxtest.Write(t, txtar.Parse([]byte(`
+-- go.work --
+go 1.22
+
+use (
+	./a
+	./b
+)
+-- a/go.mod --
+module example.com/a
+
+go 1.22
+-- a/a.go --
+package a
+-- b/go.mod --
+module example.com/b
+
+go 1.22
+-- b/b.go --
+package b
+
+import _ "example.com/a"
+`)))
+
+		args := []string{"-C", root, "-work", "go.work"}
+		Expect(t, Exec(context.Background(), args, namer{}), Succeed())
+		for _, m := range []string{"a", "b"} {
+			_, err := os.Stat(filepath.Join(root, m, Filename("pkg-namer")))
+			Expect(t, err, Succeed())
+		}
+		Expect(t, Exec(context.Background(), append(args, "-check"), namer{}), Succeed())
+		Expect(t, Exec(context.Background(), []string{"-C", dir, "-work", "go.work"}, namer{}), Failed())
+	})
+}
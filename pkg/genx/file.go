@@ -0,0 +1,130 @@
+package genx
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/types"
+	"os"
+	"path/filepath"
+
+	"github.com/xoctopus/x/misc/must"
+
+	internal "github.com/xoctopus/pkgx/internal/pkgx"
+	"github.com/xoctopus/pkgx/pkg/pkgx"
+)
+
+// NewGenFile creates a generated file named filename in package path. the
+// package must be loaded in u, and the file is placed in its source dir.
+func NewGenFile(u *pkgx.Packages, path, filename, generator string) *GenFile {
+	p := u.Package(path)
+	must.NotNilF(p, "target package `%s` is not loaded", path)
+	must.BeTrueF(p.SourceDir() != "", "target package `%s` has no source dir", path)
+
+	imports := pkgx.NewImportSet(path, u)
+	if tp := p.Unwrap(); tp != nil {
+		imports.Reserve(tp.Scope().Names()...)
+	}
+
+	return &GenFile{
+		u:         u,
+		pkg:       p,
+		filename:  filepath.Join(p.SourceDir(), filename),
+		generator: generator,
+		imports:   imports,
+	}
+}
+
+// GenFile builds a generated go source file. it collects imports while
+// rendering types, and emits header, package clause and imports when building.
+type GenFile struct {
+	u         *pkgx.Packages
+	pkg       pkgx.Package
+	filename  string
+	generator string
+	imports   *pkgx.ImportSet
+	body      bytes.Buffer
+}
+
+// Package returns target package
+func (f *GenFile) Package() pkgx.Package {
+	return f.pkg
+}
+
+// Filename returns absolute path of generated file
+func (f *GenFile) Filename() string {
+	return f.filename
+}
+
+// Imports returns import set of generated file
+func (f *GenFile) Imports() *pkgx.ImportSet {
+	return f.imports
+}
+
+// Import imports package path and returns its alias
+func (f *GenFile) Import(path string) string {
+	return f.imports.Import(path)
+}
+
+// Type renders t as go source in target package and imports referenced packages
+func (f *GenFile) Type(t types.Type) string {
+	return f.u.TypeString(t, f.imports)
+}
+
+// Write appends p to file body
+func (f *GenFile) Write(p []byte) (int, error) {
+	return f.body.Write(p)
+}
+
+// Printf appends formatted content to file body
+func (f *GenFile) Printf(format string, args ...any) {
+	_, _ = fmt.Fprintf(&f.body, format, args...)
+}
+
+// Empty reports if nothing is written to file body
+func (f *GenFile) Empty() bool {
+	return f.body.Len() == 0
+}
+
+// Bytes returns formatted file content
+func (f *GenFile) Bytes() ([]byte, error) {
+	b := bytes.NewBuffer(nil)
+	_, _ = fmt.Fprintf(b, "// Code generated by %s. DO NOT EDIT.\n\n", f.generator)
+	_, _ = fmt.Fprintf(b, "package %s\n\n", f.pkg.Name())
+
+	if specs := f.imports.Specs(); len(specs) > 0 {
+		b.WriteString("import (\n")
+		for _, spec := range specs {
+			_, _ = fmt.Fprintf(b, "\t%s\n", spec)
+		}
+		b.WriteString(")\n\n")
+	}
+	b.Write(f.body.Bytes())
+
+	code, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format %s: %w\n%s", f.filename, err, b.String())
+	}
+	return code, nil
+}
+
+// Flush writes file atomically if content changed. it reports if the file on
+// disk is changed
+func (f *GenFile) Flush() (bool, error) {
+	code, err := f.Bytes()
+	if err != nil {
+		return false, err
+	}
+	return write(f.filename, code)
+}
+
+// write writes code to filename atomically if content changed
+func write(filename string, code []byte) (bool, error) {
+	if existed, err := os.ReadFile(filename); err == nil && bytes.Equal(existed, code) {
+		return false, nil
+	}
+	if err := internal.WriteFileAtomic(filename, code, 0o644); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,95 @@
+package genx_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/xoctopus/x/testx"
+
+	. "github.com/xoctopus/pkgx/pkg/genx"
+	"github.com/xoctopus/pkgx/pkg/pkgx"
+)
+
+var (
+	_, _     = os.Setenv("GOWORK", "off"), 0
+	cwd, _   = os.Getwd()
+	testdata = "github.com/xoctopus/pkgx/testdata"
+	sub      = "github.com/xoctopus/pkgx/testdata/sub"
+)
+
+// fixture copies testdata module to a temporary dir and loads it
+func fixture(t *testing.T) (string, *pkgx.Packages) {
+	dir := t.TempDir()
+	Expect(t, os.CopyFS(dir, os.DirFS(filepath.Join(cwd, "..", "..", "testdata"))), Succeed())
+	return dir, pkgx.NewPackages(pkgx.CtxWorkdir.With(context.Background(), dir), testdata)
+}
+
+func TestGenFile(t *testing.T) {
+	dir, u := fixture(t)
+
+	f := NewGenFile(u, testdata, "zz_genx_test.go", "genx-test")
+	Expect(t, f.Filename(), Equal(filepath.Join(dir, "zz_genx_test.go")))
+	Expect(t, f.Package().Path(), Equal(testdata))
+	Expect(t, f.Empty(), BeTrue())
+
+	structure := u.Package(sub).TypeNames().ElementByName("Structure")
+	f.Printf("var _ = %s{}\n", f.Type(structure.Type()))
+	f.Printf("var Structure%d = %s.Background\n", 1, f.Import("context"))
+	_, _ = f.Write([]byte("func   Format()   {}\n"))
+	Expect(t, f.Empty(), BeFalse())
+	Expect(t, f.Imports().Specs(), HaveLen[[]pkgx.ImportSpec](2))
+
+	expect := `// Code generated by genx-test. DO NOT EDIT.
+
+package testdata
+
+import (
+	"context"
+	"github.com/xoctopus/pkgx/testdata/sub"
+)
+
+var _ = sub.Structure{}
+var Structure1 = context.Background
+
+func Format() {}
+`
+	code, err := f.Bytes()
+	Expect(t, err, Succeed())
+	Expect(t, string(code), Equal(expect))
+
+	changed, err := f.Flush()
+	Expect(t, err, Succeed())
+	Expect(t, changed, BeTrue())
+	data, _ := os.ReadFile(f.Filename())
+	Expect(t, string(data), Equal(expect))
+
+	changed, err = f.Flush()
+	Expect(t, err, Succeed())
+	Expect(t, changed, BeFalse())
+
+	t.Run("ReservedIdentifier", func(t *testing.T) {
+		f := NewGenFile(u, sub, "zz_genx_test.go", "genx-test")
+		Expect(t, f.Import("github.com/example/F"), Equal("F1"))
+	})
+
+	t.Run("FormatFailed", func(t *testing.T) {
+		f := NewGenFile(u, testdata, "zz_genx_invalid.go", "genx-test")
+		f.Printf("func {")
+		_, err := f.Bytes()
+		Expect(t, err, ErrorContains("failed to format"))
+		_, err = f.Flush()
+		Expect(t, err, Failed())
+	})
+
+	t.Run("WriteFailed", func(t *testing.T) {
+		f := NewGenFile(u, testdata, filepath.Join("go.mod", "zz_genx_test.go"), "genx-test")
+		_, err := f.Flush()
+		Expect(t, err, Failed())
+	})
+
+	t.Run("PackageNotLoaded", func(t *testing.T) {
+		ExpectPanic[error](t, func() { NewGenFile(u, "not/loaded", "zz.go", "genx-test") })
+	})
+}
@@ -0,0 +1,255 @@
+package genx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/xoctopus/x/contextx"
+	"github.com/xoctopus/x/misc/must"
+
+	internal "github.com/xoctopus/pkgx/internal/pkgx"
+	"github.com/xoctopus/pkgx/pkg/pkgx"
+)
+
+// Generator generates a file for a package
+type Generator interface {
+	// Name returns generator name, it is used in generated file header and
+	// filename
+	Name() string
+	// Generate writes generated code of package p to f, nothing will be output
+	// if f is kept empty
+	Generate(ctx context.Context, p pkgx.Package, f *GenFile) error
+}
+
+// Trigger is an optional interface of Generator. the generator only runs on
+// packages which have typenames or functions annotated by `+Trigger()`, and
+// annotated objects can be retrieved by MatchedFrom.
+type Trigger interface {
+	Trigger() string
+}
+
+// Matched carries objects annotated by trigger of generator
+type Matched struct {
+	TypeNames []*pkgx.TypeName
+	Functions []*pkgx.Function
+}
+
+func (m *Matched) Empty() bool {
+	return m == nil || len(m.TypeNames) == 0 && len(m.Functions) == 0
+}
+
+var ctxMatched = contextx.NewT[*Matched](contextx.WithDefault[*Matched](nil))
+
+// MatchedFrom returns annotated objects of current generating package. it
+// returns nil if generator has no trigger
+func MatchedFrom(ctx context.Context) *Matched {
+	return ctxMatched.MustFrom(ctx)
+}
+
+// Filename returns generated filename of generator
+func Filename(generator string) string {
+	return "zz_genx_" + strings.ReplaceAll(generator, "-", "_") + ".go"
+}
+
+var registry sync.Map
+
+// Register registers generators globally, it panics if name is duplicated
+func Register(generators ...Generator) {
+	for _, g := range generators {
+		_, loaded := registry.LoadOrStore(g.Name(), g)
+		must.BeTrueF(!loaded, "generator `%s` is registered", g.Name())
+	}
+}
+
+// Registered returns registered generators sorted by name
+func Registered() []Generator {
+	generators := make([]Generator, 0)
+	registry.Range(func(_, v any) bool {
+		generators = append(generators, v.(Generator))
+		return true
+	})
+	slices.SortFunc(generators, func(a, b Generator) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return generators
+}
+
+// NewRunner creates runner with generators, registered generators are used if
+// no generator is given
+func NewRunner(generators ...Generator) *Runner {
+	if len(generators) == 0 {
+		generators = Registered()
+	}
+	return &Runner{generators: generators}
+}
+
+// Runner loads packages once and dispatches every direct package to generators
+type Runner struct {
+	generators []Generator
+}
+
+// Output is a generated file in memory
+type Output struct {
+	Package   string
+	Generator string
+	Filename  string
+	Code      []byte
+	Matched   *Matched
+}
+
+// Write writes output to disk if content changed
+func (o *Output) Write() error {
+	_, err := write(o.Filename, o.Code)
+	return err
+}
+
+// Run loads packages matched by patterns, runs generators and writes outputs
+// whose content changed. generated files which have no output any more, such
+// as trigger annotations are removed, are deleted. go.xsum of modules are
+// refreshed after writing if they existed.
+func (r *Runner) Run(ctx context.Context, patterns ...string) ([]*Output, error) {
+	u, err := pkgx.LoadPackages(ctx, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	outputs, err := r.Generate(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	errs := make([]error, 0)
+	for _, o := range outputs {
+		errs = append(errs, o.Write())
+	}
+	for _, o := range r.stale(u, outputs) {
+		errs = append(errs, os.Remove(o.Filename))
+	}
+	if err = errors.Join(errs...); err != nil {
+		return outputs, err
+	}
+	return outputs, refreshSums(u)
+}
+
+// Generate runs generators on direct packages of u in memory, outputs are
+// ordered by package path and generator.
+func (r *Runner) Generate(ctx context.Context, u *pkgx.Packages) ([]*Output, error) {
+	outputs := make([]*Output, 0)
+	errs := make([]error, 0)
+
+	for _, p := range targets(u) {
+		for _, g := range r.generators {
+			o, err := generate(ctx, u, p, g)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %s: %w", p.Path(), g.Name(), err))
+				continue
+			}
+			if o != nil {
+				outputs = append(outputs, o)
+			}
+		}
+	}
+	return outputs, errors.Join(errs...)
+}
+
+// stale returns generated files of generators existing in target packages
+// which are not in outputs, as outputs without code. they are ordered by
+// package path and generator.
+func (r *Runner) stale(u *pkgx.Packages, outputs []*Output) []*Output {
+	generated := make(map[string]bool, len(outputs))
+	for _, o := range outputs {
+		generated[o.Filename] = true
+	}
+
+	stale := make([]*Output, 0)
+	for _, p := range targets(u) {
+		for _, g := range r.generators {
+			filename := filepath.Join(p.SourceDir(), Filename(g.Name()))
+			if !generated[filename] && exists(filename) {
+				stale = append(stale, &Output{
+					Package:   p.Path(),
+					Generator: g.Name(),
+					Filename:  filename,
+				})
+			}
+		}
+	}
+	return stale
+}
+
+// targets returns direct packages which have source dir ordered by path, test
+// packages are excluded
+func targets(u *pkgx.Packages) []pkgx.Package {
+	packages := make([]pkgx.Package, 0)
+	for _, path := range slices.Sorted(u.Directs) {
+		p := u.Package(path)
+		if p == nil || p.SourceDir() == "" || isTest(p) {
+			continue
+		}
+		packages = append(packages, p)
+	}
+	return packages
+}
+
+// isTest reports if p is test binary, external test package or test variant
+// of package, such as `x.test`, `x_test` and `x [x.test]`
+func isTest(p pkgx.Package) bool {
+	path := p.Path()
+	return strings.HasSuffix(path, ".test") || strings.HasSuffix(path, "_test") ||
+		strings.Contains(p.ID(), " [")
+}
+
+func generate(ctx context.Context, u *pkgx.Packages, p pkgx.Package, g Generator) (*Output, error) {
+	var matched *Matched
+	if t, ok := g.(Trigger); ok {
+		matched = match(p, t.Trigger())
+		if matched.Empty() {
+			return nil, nil
+		}
+	}
+
+	f := NewGenFile(u, p.Path(), Filename(g.Name()), g.Name())
+	if err := g.Generate(ctxMatched.With(ctx, matched), p, f); err != nil {
+		return nil, err
+	}
+	if f.Empty() {
+		return nil, nil
+	}
+
+	code, err := f.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return &Output{
+		Package:   p.Path(),
+		Generator: g.Name(),
+		Filename:  f.Filename(),
+		Code:      code,
+		Matched:   matched,
+	}, nil
+}
+
+func match(p pkgx.Package, trigger string) *Matched {
+	m := &Matched{}
+	annotated := func(doc []string) bool {
+		_, tags := internal.ParseLines(doc)
+		_, ok := tags[trigger]
+		return ok
+	}
+	for t := range p.TypeNames().Elements() {
+		if annotated(t.Doc()) {
+			m.TypeNames = append(m.TypeNames, t)
+		}
+	}
+	for f := range p.Functions().Elements() {
+		if annotated(f.Doc()) {
+			m.Functions = append(m.Functions, f)
+		}
+	}
+	return m
+}
@@ -0,0 +1,137 @@
+package genx_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/xoctopus/x/testx"
+	"golang.org/x/tools/txtar"
+
+	. "github.com/xoctopus/pkgx/pkg/genx"
+	"github.com/xoctopus/pkgx/pkg/pkgx"
+	"github.com/xoctopus/pkgx/pkg/pkgxtest"
+)
+
+// tagger generates `Tag1` method for typenames annotated by `+tag1`
+type tagger struct{}
+
+func (tagger) Name() string { return "tagger" }
+
+func (tagger) Trigger() string { return "tag1" }
+
+func (tagger) Generate(ctx context.Context, p pkgx.Package, f *GenFile) error {
+	for _, t := range MatchedFrom(ctx).TypeNames {
+		f.Printf("func (%s) Tag1() string { return %q }\n\n", t.Name(), t.Name())
+	}
+	return nil
+}
+
+// namer generates package name constant for every package
+type namer struct{ err error }
+
+func (namer) Name() string { return "pkg-namer" }
+
+func (g namer) Generate(ctx context.Context, p pkgx.Package, f *GenFile) error {
+	if MatchedFrom(ctx) != nil {
+		return errors.New("unexpected matched")
+	}
+	if p.Name() == "sub" {
+		return g.err
+	}
+	f.Printf("const PackageName = %q\n", p.Name())
+	return nil
+}
+
+func TestRunner(t *testing.T) {
+	dir, u := fixture(t)
+	ctx := pkgx.CtxWorkdir.With(context.Background(), dir)
+
+	outputs, err := NewRunner(tagger{}, namer{}).Generate(ctx, u)
+	Expect(t, err, Succeed())
+	Expect(t, outputs, HaveLen[[]*Output](2))
+
+	Expect(t, outputs[0].Package, Equal(testdata))
+	Expect(t, outputs[0].Generator, Equal("tagger"))
+	Expect(t, outputs[0].Filename, Equal(filepath.Join(dir, "zz_genx_tagger.go")))
+	Expect(t, len(outputs[0].Matched.TypeNames), Equal(2))
+	Expect(t, string(outputs[0].Code), Equal(`// Code generated by tagger. DO NOT EDIT.
+
+package testdata
+
+func (TypeA) Tag1() string { return "TypeA" }
+
+func (TypeB) Tag1() string { return "TypeB" }
+`))
+
+	Expect(t, outputs[1].Generator, Equal("pkg-namer"))
+	Expect(t, outputs[1].Filename, Equal(filepath.Join(dir, "zz_genx_pkg_namer.go")))
+	Expect(t, outputs[1].Matched, BeNil[*Matched]())
+
+	_, err = os.Stat(outputs[0].Filename)
+	Expect(t, os.IsNotExist(err), BeTrue())
+
+	t.Run("Run", func(t *testing.T) {
+		outputs, err = NewRunner(tagger{}, namer{}).Run(ctx, "./...")
+		Expect(t, err, Succeed())
+		for _, o := range outputs {
+			data, err := os.ReadFile(o.Filename)
+			Expect(t, err, Succeed())
+			Expect(t, data, Equal(o.Code))
+		}
+
+		// generated code is loaded in next run
+		x := pkgx.NewPackages(ctx, testdata)
+		typeA := x.Package(testdata).TypeNames().ElementByName("TypeA")
+		Expect(t, typeA.Method("Tag1"), NotBeNil[*pkgx.Function]())
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		_, err = NewRunner(namer{err: errors.New("any")}).Run(ctx, "./...")
+		Expect(t, err, ErrorEqual(sub+": pkg-namer: any"))
+	})
+}
+
+func TestRunner_Tests(t *testing.T) {
+	f := &pkgxtest.Fixture{Dir: pkgxtest.Write(t, txtar.Parse([]byte(`
+-- a.go --
+package fixture
+
+// +tag1
+type A int
+-- a_test.go --
+package fixture
+
+// +tag1
+type B int
+-- x_test.go --
+package fixture_test
+
+// +tag1
+type C int
+`)))}
+	ctx := pkgx.CtxLoadTests.With(f.Context(context.Background()), true)
+	u, err := pkgx.LoadPackages(ctx, "./...")
+	Expect(t, err, Succeed())
+
+	outputs, err := NewRunner(tagger{}).Generate(ctx, u)
+	Expect(t, err, Succeed())
+	Expect(t, outputs, HaveLen[[]*Output](1))
+	Expect(t, outputs[0].Package, Equal(pkgxtest.DefaultModule))
+	Expect(t, outputs[0].Filename, Equal(filepath.Join(f.Dir, "zz_genx_tagger.go")))
+	Expect(t, string(outputs[0].Code), ContainsSubString("func (A) Tag1()"))
+	Expect(t, string(outputs[0].Code), Not(ContainsSubString("func (B) Tag1()")))
+}
+
+func TestRegister(t *testing.T) {
+	Register(namer{}, tagger{})
+	ExpectPanic[error](t, func() { Register(tagger{}) })
+
+	generators := Registered()
+	Expect(t, generators, HaveLen[[]Generator](2))
+	Expect(t, generators[0].Name(), Equal("pkg-namer"))
+	Expect(t, generators[1].Name(), Equal("tagger"))
+	Expect(t, NewRunner(), Equal(NewRunner(generators...)))
+}
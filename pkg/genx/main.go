@@ -0,0 +1,64 @@
+package genx
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/xoctopus/pkgx/pkg/pkgx"
+)
+
+// Main is the entry of a generator command. registered generators are used if
+// no generator is given. it exits with non-zero code if failed or, in check
+// mode, generated files are out of date.
+//
+//	gen [-C dir] [-work go.work] [-check] patterns...
+func Main(generators ...Generator) {
+	if err := Exec(context.Background(), os.Args[1:], generators...); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// Exec parses command line args and runs generators. patterns default to
+// `./...` if not given, or all modules used by go.work in workspace mode.
+func Exec(ctx context.Context, args []string, generators ...Generator) error {
+	var (
+		workdir string
+		work    string
+		check   bool
+	)
+	fs := flag.NewFlagSet("genx", flag.ContinueOnError)
+	fs.StringVar(&workdir, "C", "", "change to dir before loading packages")
+	fs.StringVar(&work, "work", "", "load packages in workspace of go.work")
+	fs.BoolVar(&check, "check", false, "report generated files which are out of date instead of writing")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if workdir != "" {
+		ctx = pkgx.CtxWorkdir.With(ctx, workdir)
+	}
+	if work != "" {
+		ctx = pkgx.CtxWorkspace.With(ctx, work)
+	}
+	patterns := fs.Args()
+	if len(patterns) == 0 && work != "" {
+		w, err := pkgx.ReadWorkspace(ctx)
+		if err != nil {
+			return err
+		}
+		patterns = w.Patterns()
+	}
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	r := NewRunner(generators...)
+	if check {
+		return r.Check(ctx, patterns...)
+	}
+	_, err := r.Run(ctx, patterns...)
+	return err
+}
@@ -0,0 +1,50 @@
+package pkgx
+
+import (
+	"reflect"
+
+	"golang.org/x/tools/go/analysis"
+	gopkg "golang.org/x/tools/go/packages"
+)
+
+// FromPass builds package model from files and type info of analysis pass.
+// dependencies are not loaded, so PackageByPath of the package returns nil for
+// its imports, and module of the package has no dir.
+func FromPass(pass *analysis.Pass) Package {
+	p := &gopkg.Package{
+		ID:         pass.Pkg.Path(),
+		Name:       pass.Pkg.Name(),
+		PkgPath:    pass.Pkg.Path(),
+		Fset:       pass.Fset,
+		Syntax:     pass.Files,
+		Types:      pass.Pkg,
+		TypesInfo:  pass.TypesInfo,
+		TypesSizes: pass.TypesSizes,
+	}
+	for _, f := range pass.Files {
+		p.CompiledGoFiles = append(p.CompiledGoFiles, pass.Fset.File(f.Pos()).Name())
+	}
+	if m := pass.Module; m != nil {
+		p.Module = &gopkg.Module{Path: m.Path, Version: m.Version, GoVersion: m.GoVersion}
+	}
+
+	u := newPackages([]string{p.PkgPath}, pass.Fset)
+	u.directs.Store(p.PkgPath)
+	u.register(p, &progress{})
+	return u.Package(p.PkgPath)
+}
+
+// Analyzer is an analysis.Analyzer whose result is the pkgx Package of analyzed
+// package. analyzers depend on it can retrieve the model by
+//
+//	pass.ResultOf[pkgx.Analyzer].(pkgx.Package)
+//
+// ResultType is the concrete type of Package, because analysis drivers check
+// result type exactly.
+var Analyzer = &analysis.Analyzer{
+	Name:       "pkgx",
+	Doc:        "build pkgx model of analyzed package",
+	URL:        "https://pkg.go.dev/github.com/xoctopus/pkgx/pkg/pkgx",
+	Run:        func(pass *analysis.Pass) (any, error) { return FromPass(pass), nil },
+	ResultType: reflect.TypeFor[*xpkg](),
+}
@@ -0,0 +1,67 @@
+package pkgx_test
+
+import (
+	"testing"
+
+	. "github.com/xoctopus/x/testx"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/checker"
+	gopkg "golang.org/x/tools/go/packages"
+
+	internal "github.com/xoctopus/pkgx/internal/pkgx"
+	. "github.com/xoctopus/pkgx/pkg/pkgx"
+)
+
+// tagged reports typenames annotated by `+tag1`
+var tagged = &analysis.Analyzer{
+	Name:     "tagged",
+	Doc:      "report typenames annotated by +tag1",
+	Requires: []*analysis.Analyzer{Analyzer},
+	Run: func(pass *analysis.Pass) (any, error) {
+		p := pass.ResultOf[Analyzer].(Package)
+		for t := range p.TypeNames().Elements() {
+			if _, tags := internal.ParseLines(t.Doc()); len(tags["tag1"]) > 0 {
+				pass.Reportf(t.Ident().Pos(), "%s is tagged by %v", t.Name(), tags["tag1"])
+			}
+		}
+		return nil, nil
+	},
+}
+
+func TestAnalyzer(t *testing.T) {
+	pkgs, err := gopkg.Load(&gopkg.Config{Mode: gopkg.LoadAllSyntax | gopkg.NeedModule, Dir: dir}, testdata)
+	Expect(t, err, Succeed())
+
+	graph, err := checker.Analyze([]*analysis.Analyzer{tagged, Analyzer}, pkgs, nil)
+	Expect(t, err, Succeed())
+
+	messages := make([]string, 0)
+	checked := false
+	for act := range graph.All() {
+		Expect(t, act.Err, Succeed())
+		for _, d := range act.Diagnostics {
+			messages = append(messages, d.Message)
+		}
+		if act.Analyzer != Analyzer || !act.IsRoot {
+			continue
+		}
+
+		checked = true
+		p := act.Result.(Package)
+		Expect(t, p.Path(), Equal(testdata))
+		Expect(t, p.GoModule().Path, Equal(testdata))
+		Expect(t, p.SourceDir(), Equal(""))
+		Expect(t, p.PackageByPath(sub), BeNil[Package]())
+		Expect(t, p.PackageDoc(), Equal(pkg.PackageDoc()))
+		Expect(t, p.FieldDoc("Structure", "name"), Equal([]string{"name comments"}))
+		Expect(t, p.FileSet(), Equal(act.Package.Fset))
+
+		e := p.ElementOf(p.Unwrap().Scope().Lookup("F"))
+		Expect(t, e.Function.Name(), Equal("F"))
+	}
+	Expect(t, checked, BeTrue())
+	Expect(t, messages, Equal([]string{
+		"TypeA is tagged by [val1_1 val1_2]",
+		"TypeB is tagged by [val1_1 val1_2]",
+	}))
+}
@@ -0,0 +1,191 @@
+package pkgx
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/xoctopus/x/contextx"
+	gopkg "golang.org/x/tools/go/packages"
+
+	internal "github.com/xoctopus/pkgx/internal/pkgx"
+)
+
+// LoadSnapshot returns snapshot of direct packages matched by patterns. the
+// snapshot is read from cache dir if it is still fresh, otherwise packages are
+// loaded and the cache will be refreshed.
+func LoadSnapshot(ctx context.Context, patterns ...string) (*Snapshot, error) {
+	dir, err := CacheDir(ctx)
+	if err != nil {
+		return nil, err
+	}
+	filename := filepath.Join(dir, CacheKey(ctx, patterns...)+".json")
+
+	if s := ReadSnapshot(filename); s != nil && !s.Stale() {
+		return s, nil
+	}
+
+	u, err := LoadPackages(ctx, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	s := u.Snapshot()
+	s.GoVersion = runtime.Version()
+	s.Env = CtxEnv.MustFrom(ctx)
+	s.Workdir = CtxWorkdir.MustFrom(ctx)
+	s.Tests = CtxLoadTests.MustFrom(ctx)
+	s.Build = CtxBuild.MustFrom(ctx)
+	s.Patterns = patterns
+	if gowork := workspace(ctx); gowork != "" {
+		s.Workspace = gowork
+		s.WorkspaceSum = hashWorkspace(gowork)
+	}
+	if wildcard(patterns) {
+		if s.Listed, err = listPackages(s.context(), patterns...); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = WriteSnapshot(filename, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// CacheDir returns snapshot cache dir from context. if not assigned, it uses
+// `pkgx` under user's cache dir
+func CacheDir(ctx context.Context) (string, error) {
+	if dir := CtxCacheDir.MustFrom(ctx); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "pkgx"), nil
+}
+
+// CacheKey returns cache key of patterns under loading context. the key
+// changes when go version, build env, build context, workdir, overlay or load
+// options changed.
+func CacheKey(ctx context.Context, patterns ...string) string {
+	h := sha256.New()
+	_, _ = fmt.Fprintln(h, SnapshotVersion, runtime.Version())
+	_, _ = fmt.Fprintln(h, CtxWorkdir.MustFrom(ctx))
+	_, _ = fmt.Fprintln(h, Config(ctx).Mode, CtxLoadTests.MustFrom(ctx))
+	_, _ = fmt.Fprintln(h, CtxEnv.MustFrom(ctx), CtxBuild.MustFrom(ctx), workspace(ctx))
+	_, _ = fmt.Fprintln(h, patterns)
+
+	overlay := CtxOverlay.MustFrom(ctx)
+	for _, filename := range slices.Sorted(maps.Keys(overlay)) {
+		_, _ = fmt.Fprintln(h, filename, sha256.Sum256(overlay[filename]))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ReadSnapshot reads snapshot from file, it returns nil if file is not existed
+// or snapshot version mismatched
+func ReadSnapshot(filename string) *Snapshot {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil
+	}
+	s := &Snapshot{}
+	if err = json.Unmarshal(data, s); err != nil || s.Version != SnapshotVersion {
+		return nil
+	}
+	return s
+}
+
+// WriteSnapshot writes snapshot to file atomically
+func WriteSnapshot(filename string, s *Snapshot) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return internal.WriteFileAtomic(filename, data, 0o644)
+}
+
+// Stale reports if snapshot is out of date. it is stale when go version
+// changed, or hash of go.work/go.work.sum, any module's go.mod/go.sum or
+// package's files changed, or wildcard patterns expand to different packages
+func (s *Snapshot) Stale() bool {
+	if s.Version != SnapshotVersion || s.GoVersion != runtime.Version() {
+		return true
+	}
+	if s.Workspace != "" && s.WorkspaceSum != hashWorkspace(s.Workspace) {
+		return true
+	}
+	for _, m := range s.Modules {
+		if m.Sum != internal.HashFiles(m.Dir, "go.mod", "go.sum") {
+			return true
+		}
+	}
+	for _, p := range s.Packages {
+		if p.Hash == "" {
+			continue
+		}
+		sum := internal.NewSum(p.Dir)
+		sum.Add(&gopkg.Package{ID: p.ID, Dir: p.Dir})
+		if sum.Hash(p.ID) != p.Hash {
+			return true
+		}
+	}
+	if wildcard(s.Patterns) {
+		listed, err := listPackages(s.context(), s.Patterns...)
+		if err != nil || !slices.Equal(listed, s.Listed) {
+			return true
+		}
+	}
+	return false
+}
+
+// context restores loading context recorded in snapshot
+func (s *Snapshot) context() context.Context {
+	return contextx.Compose(
+		CtxWorkdir.Carry(s.Workdir),
+		CtxEnv.Carry(s.Env),
+		CtxLoadTests.Carry(s.Tests),
+		CtxBuild.Carry(s.Build),
+		CtxWorkspace.Carry(s.Workspace),
+	)(context.Background())
+}
+
+// wildcard reports if any pattern contains `...`, which may match packages
+// added after loading
+func wildcard(patterns []string) bool {
+	return slices.ContainsFunc(patterns, func(p string) bool {
+		return strings.Contains(p, "...")
+	})
+}
+
+// listPackages returns sorted ids of packages matched by patterns. only names
+// are loaded, so it is cheap to detect added or removed packages
+func listPackages(ctx context.Context, patterns ...string) ([]string, error) {
+	c := Config(ctx)
+	c.Mode = gopkg.NeedName
+	pkgs, err := gopkg.Load(c, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(pkgs))
+	for _, p := range pkgs {
+		ids = append(ids, p.ID)
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+// hashWorkspace hashes go.work file and its sum file
+func hashWorkspace(filename string) string {
+	name := filepath.Base(filename)
+	return internal.HashFiles(filepath.Dir(filename), name, name+".sum")
+}
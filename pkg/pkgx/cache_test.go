@@ -0,0 +1,140 @@
+package pkgx_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xoctopus/x/contextx"
+	. "github.com/xoctopus/x/testx"
+	"golang.org/x/tools/txtar"
+
+	. "github.com/xoctopus/pkgx/pkg/pkgx"
+	"github.com/xoctopus/pkgx/pkg/pkgxtest"
+)
+
+func TestLoadSnapshot(t *testing.T) {
+	cache := t.TempDir()
+	ctx := contextx.Compose(
+		CtxWorkdir.Carry(dir),
+		CtxCacheDir.Carry(cache),
+	)(context.Background())
+
+	filename := filepath.Join(cache, CacheKey(ctx, testdata)+".json")
+
+	s, err := LoadSnapshot(ctx, testdata)
+	Expect(t, err, Succeed())
+	Expect(t, s.Stale(), BeFalse())
+	Expect(t, s.Patterns, Equal([]string{testdata}))
+	Expect(t, s.Modules, HaveLen[[]*ModuleSnapshot](1))
+	Expect(t, s.Modules[0].Path, Equal(testdata))
+
+	p := s.Package(testdata)
+	Expect(t, p, NotBeNil[*PackageSnapshot]())
+	Expect(t, p.Hash, NotEqual(""))
+	Expect(t, p.Doc, Equal(pkg.PackageDoc()))
+	Expect(t, p.Constant("IntConstTypeValue3").Value, Equal("4"))
+	Expect(t, p.Function("F").Signature, Equal("func()"))
+	Expect(t, p.Function("_"), BeNil[*FunctionSnapshot]())
+
+	n := p.TypeName("Structure")
+	Expect(t, n.Kind, Equal("struct"))
+	Expect(t, n.Fields[0].Doc, Equal([]string{"name comments"}))
+	Expect(t, n.Methods, HaveLen[[]*FunctionSnapshot](3))
+	Expect(t, p.TypeName("Float").Alias, BeTrue())
+	Expect(t, p.TypeName("_"), BeNil[*TypeNameSnapshot]())
+
+	t.Run("HitCache", func(t *testing.T) {
+		cached := ReadSnapshot(filename)
+		Expect(t, cached, NotBeNil[*Snapshot]())
+		cached.Package(testdata).Doc = []string{"from cache"}
+		Expect(t, WriteSnapshot(filename, cached), Succeed())
+
+		s, err = LoadSnapshot(ctx, testdata)
+		Expect(t, err, Succeed())
+		Expect(t, s.Package(testdata).Doc, Equal([]string{"from cache"}))
+	})
+
+	t.Run("Invalidated", func(t *testing.T) {
+		cached := ReadSnapshot(filename)
+		cached.Package(testdata).Hash = "h1:changed"
+		Expect(t, cached.Stale(), BeTrue())
+		Expect(t, WriteSnapshot(filename, cached), Succeed())
+
+		s, err = LoadSnapshot(ctx, testdata)
+		Expect(t, err, Succeed())
+		Expect(t, s.Package(testdata).Doc, Equal(pkg.PackageDoc()))
+
+		cached = ReadSnapshot(filename)
+		cached.Modules[0].Sum = "h1:changed"
+		Expect(t, cached.Stale(), BeTrue())
+		cached.GoVersion = "go1"
+		Expect(t, cached.Stale(), BeTrue())
+	})
+
+	t.Run("InvalidCacheFile", func(t *testing.T) {
+		Expect(t, ReadSnapshot(filepath.Join(cache, "not_exists")), BeNil[*Snapshot]())
+		Expect(t, os.WriteFile(filename, []byte("{"), os.ModePerm), Succeed())
+		Expect(t, ReadSnapshot(filename), BeNil[*Snapshot]())
+	})
+
+	t.Run("Workspace", func(t *testing.T) {
+		root := pkgxtest.Write(t, txtar.Parse(workspace))
+		ctx := contextx.Compose(
+			CtxWorkdir.Carry(root),
+			CtxWorkspace.Carry("go.work"),
+			CtxCacheDir.Carry(cache),
+			// -mod=mod is not allowed in workspace mode
+			CtxEnv.Carry(append(CtxEnv.MustFrom(context.Background()), "GOFLAGS=")),
+		)(context.Background())
+
+		s, err := LoadSnapshot(ctx, "example.com/svc")
+		Expect(t, err, Succeed())
+		Expect(t, s.Workspace, Equal(filepath.Join(root, "go.work")))
+		Expect(t, s.Stale(), BeFalse())
+
+		gowork := filepath.Join(root, "go.work")
+		data, err := os.ReadFile(gowork)
+		Expect(t, err, Succeed())
+		Expect(t, os.WriteFile(gowork, append(data, "\nreplace example.com/x => ./x\n"...), 0o644), Succeed())
+		Expect(t, s.Stale(), BeTrue())
+
+		Expect(t, os.WriteFile(gowork, data, 0o644), Succeed())
+		Expect(t, s.Stale(), BeFalse())
+		Expect(t, os.WriteFile(gowork+".sum", []byte("example.com/x v1.0.0 h1:x\n"), 0o644), Succeed())
+		Expect(t, s.Stale(), BeTrue())
+	})
+
+	t.Run("NewPackage", func(t *testing.T) {
+		root := pkgxtest.Write(t, txtar.Parse([]byte("-- a/a.go --\npackage a\n")))
+		ctx := contextx.Compose(
+			CtxWorkdir.Carry(root),
+			CtxCacheDir.Carry(cache),
+		)(context.Background())
+
+		s, err := LoadSnapshot(ctx, "./...")
+		Expect(t, err, Succeed())
+		Expect(t, s.Listed, Equal([]string{pkgxtest.DefaultModule + "/a"}))
+		Expect(t, s.Stale(), BeFalse())
+
+		Expect(t, os.MkdirAll(filepath.Join(root, "b"), 0o755), Succeed())
+		Expect(t, os.WriteFile(filepath.Join(root, "b", "b.go"), []byte("package b\n"), 0o644), Succeed())
+		Expect(t, s.Stale(), BeTrue())
+
+		s, err = LoadSnapshot(ctx, "./...")
+		Expect(t, err, Succeed())
+		Expect(t, s.Package(pkgxtest.DefaultModule+"/b"), NotBeNil[*PackageSnapshot]())
+		Expect(t, s.Stale(), BeFalse())
+	})
+
+	t.Run("CacheKey", func(t *testing.T) {
+		Expect(t, CacheKey(ctx, testdata), Equal(CacheKey(ctx, testdata)))
+		Expect(t, CacheKey(ctx, testdata), NotEqual(CacheKey(ctx, sub)))
+		Expect(t, CacheKey(ctx, testdata), NotEqual(CacheKey(CtxEnv.With(ctx, nil), testdata)))
+
+		d, err := CacheDir(context.Background())
+		Expect(t, err, Succeed())
+		Expect(t, filepath.Base(d), Equal("pkgx"))
+	})
+}
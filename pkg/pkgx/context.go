@@ -1,14 +1,22 @@
 package pkgx
 
 import (
+	"cmp"
 	"context"
 	"go/token"
+	"maps"
 	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
 
 	"github.com/xoctopus/x/contextx"
 	gopkg "golang.org/x/tools/go/packages"
 )
 
+// PkgNamer resolves package name by package id(import path), it returns empty
+// if the package is unknown
 type PkgNamer interface {
 	PackageName(id string) (name string)
 }
@@ -29,15 +37,117 @@ var (
 	CtxLoadTests = contextx.NewT[bool](contextx.WithDefault(false))
 	CtxFileset   = contextx.NewT[*token.FileSet](contextx.WithDefault[*token.FileSet](nil))
 	CtxEnv       = contextx.NewT[[]string](contextx.WithDefault([]string{"GOWORK=off", "GOEXPERIMENT="}))
+	CtxCacheDir  = contextx.NewT[string](contextx.WithDefault(""))
+	CtxBuild     = contextx.NewT[BuildContext](contextx.WithDefault(BuildContext{}))
+	CtxOverlay   = contextx.NewT[map[string][]byte](contextx.WithDefault[map[string][]byte](nil))
+	// CtxDepsSyntax controls if dependencies are loaded with syntax and type
+	// info. if false, only packages matched by patterns keep syntax, others are
+	// loaded from export data and their models are built from types.Scope,
+	// documents of them are parsed from source files on demand. it bounds memory
+	// of loading large modules, and packages of entry modules should be matched
+	// by patterns, such as `./...`, to keep their syntax.
+	CtxDepsSyntax = contextx.NewT[bool](contextx.WithDefault(true))
+	// CtxWorkers limits concurrent workers indexing packages, non-positive
+	// value means runtime.GOMAXPROCS(0)
+	CtxWorkers = contextx.NewT[int](contextx.WithDefault(0))
+	// CtxWorkspace enables workspace mode with filename of go.work, relative
+	// filename is resolved against workdir. in workspace mode, packages are
+	// loaded with GOWORK set to it and every module used by go.work is treated
+	// as entry module. see LoadWorkspace
+	CtxWorkspace = contextx.NewT[string](contextx.WithDefault(""))
 )
 
+// Workers returns worker limit of indexing packages carried by ctx
+func Workers(ctx context.Context) int {
+	if n := CtxWorkers.MustFrom(ctx); n > 0 {
+		return n
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// WithOverlay carries in-memory file contents replacing or adding source files
+// for loading. relative filenames are resolved against workdir, and files are
+// merged with overlay already carried by ctx.
+func WithOverlay(ctx context.Context, files map[string][]byte) context.Context {
+	overlay := maps.Clone(CtxOverlay.MustFrom(ctx))
+	if overlay == nil {
+		overlay = make(map[string][]byte, len(files))
+	}
+
+	workdir := CtxWorkdir.MustFrom(ctx)
+	for filename, content := range files {
+		if !filepath.IsAbs(filename) {
+			filename, _ = filepath.Abs(filepath.Join(workdir, filename))
+		}
+		overlay[filename] = content
+	}
+	return CtxOverlay.With(ctx, overlay)
+}
+
+// NewPackagesWithOverlay loads packages with in-memory file contents
+func NewPackagesWithOverlay(ctx context.Context, files map[string][]byte, patterns ...string) *Packages {
+	return NewPackages(WithOverlay(ctx, files), patterns...)
+}
+
+// BuildContext describes target platform and build tags for loading. empty
+// GOOS or GOARCH means using host's
+type BuildContext struct {
+	GOOS   string
+	GOARCH string
+	Tags   []string
+}
+
+func (c BuildContext) String() string {
+	b := strings.Builder{}
+	b.WriteString(cmp.Or(c.GOOS, runtime.GOOS))
+	b.WriteString("/")
+	b.WriteString(cmp.Or(c.GOARCH, runtime.GOARCH))
+	// tags are sorted, order of tags is irrelevant to build
+	for _, tag := range slices.Sorted(slices.Values(c.Tags)) {
+		b.WriteString(",")
+		b.WriteString(tag)
+	}
+	return b.String()
+}
+
+func (c BuildContext) env() []string {
+	env := make([]string, 0, 2)
+	if c.GOOS != "" {
+		env = append(env, "GOOS="+c.GOOS)
+	}
+	if c.GOARCH != "" {
+		env = append(env, "GOARCH="+c.GOARCH)
+	}
+	return env
+}
+
+func (c BuildContext) flags() []string {
+	if len(c.Tags) == 0 {
+		return nil
+	}
+	return []string{"-tags=" + strings.Join(c.Tags, ",")}
+}
+
 func Config(ctx context.Context) *gopkg.Config {
+	build := CtxBuild.MustFrom(ctx)
+	mode := CtxLoadMode.MustFrom(ctx)
+	if !CtxDepsSyntax.MustFrom(ctx) {
+		// export files are required to complete types of indirect dependencies
+		mode = mode&^gopkg.NeedDeps | gopkg.NeedExportFile
+	}
+	env := slices.Concat(os.Environ(), CtxEnv.MustFrom(ctx), build.env())
+	if gowork := workspace(ctx); gowork != "" {
+		env = append(env, "GOWORK="+gowork)
+	}
 	return &gopkg.Config{
-		Fset:  CtxFileset.MustFrom(ctx),
-		Mode:  CtxLoadMode.MustFrom(ctx),
-		Logf:  CtxLogger.MustFrom(ctx),
-		Dir:   CtxWorkdir.MustFrom(ctx),
-		Tests: CtxLoadTests.MustFrom(ctx),
-		Env:   append(os.Environ(), CtxEnv.MustFrom(ctx)...),
+		Context:    ctx,
+		Fset:       CtxFileset.MustFrom(ctx),
+		Mode:       mode,
+		Logf:       CtxLogger.MustFrom(ctx),
+		Dir:        CtxWorkdir.MustFrom(ctx),
+		Tests:      CtxLoadTests.MustFrom(ctx),
+		Env:        env,
+		BuildFlags: build.flags(),
+		Overlay:    CtxOverlay.MustFrom(ctx),
 	}
 }
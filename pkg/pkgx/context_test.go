@@ -3,13 +3,18 @@ package pkgx_test
 import (
 	"context"
 	"fmt"
+	"go/ast"
 	"go/token"
+	"go/types"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sync"
 	"testing"
 
 	"github.com/xoctopus/x/contextx"
 	. "github.com/xoctopus/x/testx"
+	gopkg "golang.org/x/tools/go/packages"
 
 	. "github.com/xoctopus/pkgx/pkg/pkgx"
 )
@@ -40,4 +45,166 @@ func TestConfig(t *testing.T) {
 	Expect(t, defv.Dir, Equal(workdir))
 	Expect(t, defv.Logf, NotBeNil[func(string, ...any)]())
 	Expect(t, defv.Fset, NotBeNil[*token.FileSet]())
+
+	t.Run("BuildContext", func(t *testing.T) {
+		Expect(t, Config(ctx).BuildFlags, HaveLen[[]string](0))
+
+		build := BuildContext{GOOS: "windows", GOARCH: "arm64", Tags: []string{"a", "b"}}
+		defv = Config(CtxBuild.With(ctx, build))
+		Expect(t, defv.BuildFlags, Equal([]string{"-tags=a,b"}))
+		Expect(t, defv.Env[len(defv.Env)-2:], Equal([]string{"GOOS=windows", "GOARCH=arm64"}))
+		Expect(t, build.String(), Equal("windows/arm64,a,b"))
+		unordered := BuildContext{GOOS: "windows", GOARCH: "arm64", Tags: []string{"b", "a"}}
+		Expect(t, unordered.String(), Equal(build.String()))
+		Expect(t, unordered.Tags, Equal([]string{"b", "a"}))
+		Expect(t, BuildContext{}.String(), Equal(runtime.GOOS+"/"+runtime.GOARCH))
+	})
+}
+
+func TestWithOverlay(t *testing.T) {
+	root := t.TempDir()
+	Expect(t, os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/overlay\n\ngo 1.25\n"), os.ModePerm), Succeed())
+	Expect(t, os.WriteFile(filepath.Join(root, "a.go"), []byte("package overlay\n\nconst A = 1\n"), os.ModePerm), Succeed())
+
+	ctx := CtxWorkdir.With(context.Background(), root)
+	ctx = WithOverlay(ctx, map[string][]byte{"a.go": []byte("package overlay\n\n// A in memory\nconst A = 2\n")})
+	ctx = WithOverlay(ctx, map[string][]byte{filepath.Join(root, "b.go"): []byte("package overlay\n\nconst B = A * 2\n")})
+
+	overlay := CtxOverlay.MustFrom(ctx)
+	Expect(t, overlay, HaveKey[string, []byte, map[string][]byte](filepath.Join(root, "a.go")))
+	Expect(t, overlay, HaveKey[string, []byte, map[string][]byte](filepath.Join(root, "b.go")))
+	Expect(t, Config(ctx).Overlay, HaveLen[map[string][]byte](2))
+
+	u := NewPackages(ctx, "example.com/overlay")
+	p := u.Package("example.com/overlay")
+	Expect(t, p.Constants().ElementByName("A").Value().ExactString(), Equal("2"))
+	Expect(t, p.Constants().ElementByName("A").Doc(), Equal([]string{"A in memory"}))
+	Expect(t, p.Constants().ElementByName("B").Value().ExactString(), Equal("4"))
+
+	u = NewPackagesWithOverlay(CtxWorkdir.With(context.Background(), root), nil, "example.com/overlay")
+	p = u.Package("example.com/overlay")
+	Expect(t, p.Constants().ElementByName("A").Value().ExactString(), Equal("1"))
+	Expect(t, p.Constants().ElementByName("B"), BeNil[*Constant]())
+}
+
+func TestDepsSyntax(t *testing.T) {
+	ctx := contextx.Compose(
+		CtxWorkdir.Carry(dir),
+		CtxDepsSyntax.Carry(false),
+	)(context.Background())
+	Expect(t, Config(ctx).Mode, Equal(DefaultLoadMode&^gopkg.NeedDeps|gopkg.NeedExportFile))
+
+	x := NewPackages(ctx, testdata)
+	p := x.Package(testdata)
+	Expect(t, p.TypeNames().Len(), Equal(pkg.TypeNames().Len()))
+	Expect(t, p.PackageDoc(), Equal(pkg.PackageDoc()))
+
+	std := x.Package("fmt")
+	Expect(t, std.Unwrap().Scope().Lookup("Stringer"), NotBeNil[types.Object]())
+	Expect(t, std.Files(), HaveLen[[]*ast.File](0))
+	Expect(t, std.GoPackage().TypesInfo, BeNil[*types.Info]())
+
+	t.Run("ModelWithoutSyntax", func(t *testing.T) {
+		names := func(p Package) (names []string) {
+			for v := range p.TypeNames().Elements() {
+				names = append(names, v.Name())
+			}
+			return names
+		}
+
+		dep, full := x.Package(sub), u.Package(sub)
+		Expect(t, dep.Files(), HaveLen[[]*ast.File](0))
+		Expect(t, names(dep), Equal(names(full)))
+		Expect(t, dep.Functions().Len(), Equal(full.Functions().Len()))
+
+		s := dep.TypeNames().ElementByName("Structure")
+		Expect(t, s.Methods().Len(), Equal(full.TypeNames().ElementByName("Structure").Methods().Len()))
+		Expect(t, s.Method("String").Exposer().Signature().Recv(), NotBeNil[*types.Var]())
+
+		e := x.Lookup(sub + ".Structure.Name")
+		Expect(t, e.Function, Equal(s.Method("Name")))
+		Expect(t, x.ElementOf(e.Object()), Equal(e))
+		Expect(t, dep.ObjectOf(ast.NewIdent("Structure")), BeNil[types.Object]())
+
+		// context is a dependency of sub, its types are completed from export data
+		indirect := x.Package("context")
+		Expect(t, indirect.Unwrap().Complete(), BeTrue())
+		for v := range u.Package("context").TypeNames().Elements() {
+			if v.Exposer().Exported() {
+				Expect(t, indirect.TypeNames().ElementByName(v.Name()), NotBeNil[*TypeName]())
+			}
+		}
+		Expect(t, indirect.Functions().ElementByName("WithCancel"), NotBeNil[*Function]())
+		param := dep.Functions().ElementByName("Do").Exposer().Signature().Params().At(0)
+		Expect(t, param.Type().(*types.Named).Obj(), Equal(indirect.TypeNames().ElementByName("Context").Exposer()))
+
+		stringer := std.TypeNames().ElementByName("Stringer")
+		Expect(t, stringer.Exposer(), Equal(std.Unwrap().Scope().Lookup("Stringer").(*types.TypeName)))
+		Expect(t, std.Functions().ElementByName("Println"), NotBeNil[*Function]())
+	})
+
+	t.Run("DocOnDemand", func(t *testing.T) {
+		dep, full := x.Package(sub), u.Package(sub)
+		for v := range full.TypeNames().Elements() {
+			d := dep.TypeNames().ElementByName(v.Name())
+			Expect(t, d.Doc(), Equal(v.Doc()))
+			if st, ok := v.Type().Underlying().(*types.Struct); ok {
+				for f := range st.Fields() {
+					Expect(t, dep.FieldDoc(v.Name(), f.Name()), Equal(full.FieldDoc(v.Name(), f.Name())))
+				}
+			}
+			for name, m := range v.Methods().Range {
+				Expect(t, d.Method(name).Doc(), Equal(m.Doc()))
+			}
+		}
+		for v := range full.Functions().Elements() {
+			Expect(t, dep.Functions().ElementByName(v.Name()).Doc(), Equal(v.Doc()))
+		}
+
+		s := dep.TypeNames().ElementByName("Structure")
+		Expect(t, s.Doc(), Equal(full.TypeNames().ElementByName("Structure").Doc()))
+		Expect(t, dep.DocByPos(s.Ident().Pos()), Equal(s.Doc()))
+		Expect(t, x.Lookup(sub+".Structure.Name").Doc(), Equal(s.Method("Name").Doc()))
+
+		ctxType := x.Package("context").TypeNames().ElementByName("Context")
+		Expect(t, ctxType.Doc(), Equal(u.Package("context").TypeNames().ElementByName("Context").Doc()))
+
+		stringer := std.TypeNames().ElementByName("Stringer")
+		Expect(t, stringer.Doc(), Not(HaveLen[[]string](0)))
+		Expect(t, std.Functions().ElementByName("Println").Doc()[0], HavePrefix("Println formats"))
+	})
+}
+
+func TestLazyModel(t *testing.T) {
+	x := NewPackages(CtxWorkdir.With(context.Background(), dir), testdata)
+	p := x.Package(testdata)
+
+	wg := sync.WaitGroup{}
+	results := make([]TypeNames, 8)
+	for i := range results {
+		wg.Go(func() { results[i] = p.TypeNames() })
+	}
+	wg.Wait()
+	for _, r := range results {
+		Expect(t, r, Equal(results[0]))
+		Expect(t, r.Len(), Equal(pkg.TypeNames().Len()))
+	}
+}
+
+func TestWorkers(t *testing.T) {
+	Expect(t, Workers(context.Background()), Equal(runtime.GOMAXPROCS(0)))
+	Expect(t, Workers(CtxWorkers.With(context.Background(), -1)), Equal(runtime.GOMAXPROCS(0)))
+	Expect(t, Workers(CtxWorkers.With(context.Background(), 3)), Equal(3))
+
+	// results are deterministic regardless of workers
+	var snapshots []*Snapshot
+	for _, n := range []int{1, 8} {
+		ctx := contextx.Compose(
+			CtxWorkdir.Carry(dir),
+			CtxWorkers.Carry(n),
+		)(context.Background())
+		snapshots = append(snapshots, NewPackages(ctx, "./...").Snapshot())
+	}
+	Expect(t, snapshots[0], Equal(snapshots[1]))
+	Expect(t, snapshots[0].Packages, HaveLen[[]*PackageSnapshot](2))
 }
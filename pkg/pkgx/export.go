@@ -0,0 +1,75 @@
+package pkgx
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+type ExportOptions struct {
+	// Dependencies includes all loaded packages, otherwise only direct
+	// packages are exported
+	Dependencies bool
+	// Relative trims module dir from source file paths, makes the document
+	// stable across machines
+	Relative bool
+	// Indent is the indent of json document, empty means compact
+	Indent string
+}
+
+// Export writes a json document of the packages model
+func (u *Packages) Export(w io.Writer, opts ExportOptions) error {
+	paths := slices.Sorted(u.Directs)
+	if opts.Dependencies {
+		paths = u.packages.Keys()
+		slices.Sort(paths)
+	}
+
+	s := u.snapshotOf(paths)
+	if opts.Relative {
+		s.relative()
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", opts.Indent)
+	return enc.Encode(s)
+}
+
+func (s *Snapshot) relative() {
+	dirs := make(map[string]string)
+	for _, m := range s.Modules {
+		dirs[m.Path] = m.Dir
+		m.Dir = ""
+	}
+
+	for _, p := range s.Packages {
+		root := dirs[p.Module]
+		if root == "" {
+			continue
+		}
+		rel := func(path string) string {
+			if r, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(r, "..") {
+				return filepath.ToSlash(r)
+			}
+			return path
+		}
+		p.Dir = rel(p.Dir)
+		for _, t := range p.TypeNames {
+			t.Position.Filename = rel(t.Position.Filename)
+			for _, f := range t.Fields {
+				f.Position.Filename = rel(f.Position.Filename)
+			}
+			for _, f := range t.Methods {
+				f.Position.Filename = rel(f.Position.Filename)
+			}
+		}
+		for _, c := range p.Constants {
+			c.Position.Filename = rel(c.Position.Filename)
+		}
+		for _, f := range p.Functions {
+			f.Position.Filename = rel(f.Position.Filename)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package pkgx_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	. "github.com/xoctopus/x/testx"
+
+	. "github.com/xoctopus/pkgx/pkg/pkgx"
+)
+
+func TestPackages_Export(t *testing.T) {
+	t.Run("Directs", func(t *testing.T) {
+		b := bytes.NewBuffer(nil)
+		Expect(t, u.Export(b, ExportOptions{Relative: true, Indent: "  "}), Succeed())
+
+		s := &Snapshot{}
+		Expect(t, json.Unmarshal(b.Bytes(), s), Succeed())
+		Expect(t, s.Version, Equal(SnapshotVersion))
+		Expect(t, s.Package("fmt"), BeNil[*PackageSnapshot]())
+		Expect(t, s.Modules[0].Dir, Equal(""))
+
+		p := s.Package(testdata)
+		Expect(t, p.Direct, BeTrue())
+		Expect(t, p.Dir, Equal("."))
+		Expect(t, s.Package(sub).Dir, Equal("sub"))
+
+		c := p.Constant("IntConstTypeValue1")
+		Expect(t, c.Type, Equal(testdata+".IntConstType"))
+		Expect(t, c.Position, Equal(Position{Filename: "documents.go", Line: 21, Column: 2, Offset: 339}))
+
+		n := p.TypeName("Structure")
+		Expect(t, n.Underlying, Equal("struct{name string; fieldX any}"))
+		Expect(t, n.Fields[1].Position.Filename, Equal("documents.go"))
+		Expect(t, n.Methods[0].Name, Equal("Name"))
+		Expect(t, n.Methods[0].PtrRecv, BeTrue())
+		Expect(t, n.Methods[0].Position.Filename, Equal("documents.go"))
+		Expect(t, p.Function("F").Position.Filename, Equal("functions.go"))
+
+		again := bytes.NewBuffer(nil)
+		Expect(t, u.Export(again, ExportOptions{Relative: true, Indent: "  "}), Succeed())
+		Expect(t, again.String(), Equal(b.String()))
+	})
+
+	t.Run("Dependencies", func(t *testing.T) {
+		b := bytes.NewBuffer(nil)
+		Expect(t, u.Export(b, ExportOptions{Dependencies: true}), Succeed())
+
+		s := &Snapshot{}
+		Expect(t, json.Unmarshal(b.Bytes(), s), Succeed())
+
+		p := s.Package("fmt")
+		Expect(t, p, NotBeNil[*PackageSnapshot]())
+		Expect(t, p.Direct, BeFalse())
+		Expect(t, p.Function("Sprintf").Signature, Equal("func(format string, a ...any) string"))
+		Expect(t, s.Package(testdata).Direct, BeTrue())
+	})
+}
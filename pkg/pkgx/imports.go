@@ -0,0 +1,188 @@
+package pkgx
+
+import (
+	"cmp"
+	"go/token"
+	"go/types"
+	"maps"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// NewImportSet creates an import set for target package path. namer is used to
+// resolve package name of import path, if namer is nil or it cannot resolve,
+// the name is guessed from import path.
+func NewImportSet(target string, namer PkgNamer) *ImportSet {
+	return &ImportSet{
+		target:   target,
+		namer:    namer,
+		aliases:  make(map[string]string),
+		names:    make(map[string]string),
+		reserved: make(map[string]string),
+	}
+}
+
+// ImportSet collects imports of a target package, and allocates non-conflicting
+// alias for each imported package
+type ImportSet struct {
+	target string
+	namer  PkgNamer
+	// aliases maps import path to allocated alias
+	aliases map[string]string
+	// names maps import path to its package name
+	names map[string]string
+	// reserved maps reserved identifier to import path, empty path means it is
+	// reserved by target package
+	reserved map[string]string
+}
+
+// ImportSpec is an import declaration, Alias is empty if it is same as package
+// name
+type ImportSpec struct {
+	Path  string
+	Alias string
+}
+
+func (s ImportSpec) String() string {
+	if s.Alias == "" {
+		return strconv.Quote(s.Path)
+	}
+	return s.Alias + " " + strconv.Quote(s.Path)
+}
+
+// Target returns target package path
+func (s *ImportSet) Target() string {
+	return s.target
+}
+
+// Reserve reserves identifiers declared in target package, import aliases
+// will not conflict with them
+func (s *ImportSet) Reserve(names ...string) {
+	for _, name := range names {
+		if _, ok := s.reserved[name]; !ok {
+			s.reserved[name] = ""
+		}
+	}
+}
+
+// Import imports package path and returns its alias. it returns empty if path
+// is target package
+func (s *ImportSet) Import(path string) string {
+	return s.use(s.namer, path, "")
+}
+
+// Qualifier is a types.Qualifier which imports package p
+func (s *ImportSet) Qualifier(p *types.Package) string {
+	return s.use(s.namer, p.Path(), p.Name())
+}
+
+// Specs returns import specs sorted by import path
+func (s *ImportSet) Specs() []ImportSpec {
+	specs := make([]ImportSpec, 0, len(s.aliases))
+	for _, path := range slices.Sorted(maps.Keys(s.aliases)) {
+		spec := ImportSpec{Path: path}
+		if alias := s.aliases[path]; alias != s.names[path] {
+			spec.Alias = alias
+		}
+		specs = append(specs, spec)
+	}
+	return specs
+}
+
+func (s *ImportSet) use(namer PkgNamer, path, name string) string {
+	if path == s.target {
+		return ""
+	}
+	if alias, ok := s.aliases[path]; ok {
+		return alias
+	}
+
+	if namer != nil {
+		if n := namer.PackageName(path); n != "" {
+			name = n
+		}
+	}
+	if name == "" {
+		name = GuessPackageName(path)
+	}
+	s.names[path] = name
+
+	alias := name
+	if !token.IsIdentifier(alias) || alias == "_" {
+		alias = "pkg"
+	}
+	for i := 1; ; i++ {
+		if _, conflicted := s.reserved[alias]; !conflicted && !isPredeclared(alias) {
+			break
+		}
+		alias = name + strconv.Itoa(i)
+		if !token.IsIdentifier(alias) {
+			alias = "pkg" + strconv.Itoa(i)
+		}
+	}
+
+	s.aliases[path] = alias
+	s.reserved[alias] = path
+	return alias
+}
+
+// GuessPackageName guesses package name from import path, major version suffix
+// like `/v2` and `.v3` is trimmed, and characters invalid in identifier are
+// dropped
+func GuessPackageName(importPath string) string {
+	name := path.Base(importPath)
+	if isMajorVersion(name) {
+		if dir := path.Dir(importPath); dir != "." && dir != "/" {
+			name = path.Base(dir)
+		}
+	}
+	if i := strings.LastIndex(name, ".v"); i > 0 && isMajorVersion(name[i+1:]) {
+		name = name[:i]
+	}
+	name = strings.TrimPrefix(name, "go-")
+	name = strings.TrimSuffix(name, "-go")
+
+	b := strings.Builder{}
+	for _, c := range name {
+		if c == '_' || unicode.IsLetter(c) || (unicode.IsDigit(c) && b.Len() > 0) {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+func isMajorVersion(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+	_, err := strconv.Atoi(s[1:])
+	return err == nil
+}
+
+func isPredeclared(name string) bool {
+	return types.Universe.Lookup(name) != nil
+}
+
+// PackageName implements PkgNamer, it returns name of loaded package by path
+func (u *Packages) PackageName(id string) string {
+	if p := u.Package(id); p != nil {
+		return p.Name()
+	}
+	return ""
+}
+
+// TypeString renders t as go source in imports' target package, packages
+// referenced by t are imported into imports. untyped basic type is rendered as
+// its default type.
+func (u *Packages) TypeString(t types.Type, imports *ImportSet) string {
+	namer := cmp.Or[PkgNamer](imports.namer, u)
+	if b, ok := t.(*types.Basic); ok && b.Info()&types.IsUntyped != 0 {
+		t = types.Default(t)
+	}
+	return types.TypeString(t, func(p *types.Package) string {
+		return imports.use(namer, p.Path(), p.Name())
+	})
+}
@@ -0,0 +1,101 @@
+package pkgx_test
+
+import (
+	"go/types"
+	"testing"
+
+	. "github.com/xoctopus/x/testx"
+
+	. "github.com/xoctopus/pkgx/pkg/pkgx"
+)
+
+func TestImportSet(t *testing.T) {
+	s := NewImportSet("example.com/target", nil)
+	s.Reserve("errors", "errors")
+
+	Expect(t, s.Target(), Equal("example.com/target"))
+	Expect(t, s.Import("example.com/target"), Equal(""))
+	Expect(t, s.Import("errors"), Equal("errors1"))
+	Expect(t, s.Import("github.com/pkg/errors"), Equal("errors2"))
+	Expect(t, s.Import("errors"), Equal("errors1"))
+	Expect(t, s.Import("gopkg.in/yaml.v3"), Equal("yaml"))
+	Expect(t, s.Import("math/rand/v2"), Equal("rand"))
+	Expect(t, s.Import("example.com/go-redis"), Equal("redis"))
+	Expect(t, s.Import("example.com/x/string"), Equal("string1"))
+	Expect(t, s.Import("example.com/x/func"), Equal("pkg"))
+	Expect(t, s.Import("example.com/x/1x"), Equal("x"))
+	Expect(t, s.Qualifier(types.NewPackage("example.com/y/v2", "realname")), Equal("realname"))
+
+	Expect(t, s.Specs(), Equal([]ImportSpec{
+		{Path: "errors", Alias: "errors1"},
+		{Path: "example.com/go-redis"},
+		{Path: "example.com/x/1x"},
+		{Path: "example.com/x/func", Alias: "pkg"},
+		{Path: "example.com/x/string", Alias: "string1"},
+		{Path: "example.com/y/v2"},
+		{Path: "github.com/pkg/errors", Alias: "errors2"},
+		{Path: "gopkg.in/yaml.v3"},
+		{Path: "math/rand/v2"},
+	}))
+	Expect(t, s.Specs()[0].String(), Equal(`errors1 "errors"`))
+	Expect(t, s.Specs()[1].String(), Equal(`"example.com/go-redis"`))
+
+	Expect(t, GuessPackageName("v2"), Equal("v2"))
+	Expect(t, GuessPackageName("example.com/x.v"), Equal("xv"))
+}
+
+func TestPackages_TypeString(t *testing.T) {
+	Expect(t, u.PackageName(sub), Equal("sub"))
+	Expect(t, u.PackageName("not/loaded"), Equal(""))
+
+	typeOf := func(name string) types.Type {
+		return pkg.TypeNames().ElementByName(name).Type()
+	}
+
+	t.Run("InTargetPackage", func(t *testing.T) {
+		s := NewImportSet(testdata, nil)
+		Expect(t, u.TypeString(typeOf("Structure"), s), Equal("Structure"))
+		Expect(t, u.TypeString(types.NewPointer(typeOf("StructureAlias")), s), Equal("*StructureAlias"))
+		Expect(t, u.TypeString(typeOf("Float"), s), Equal("Float"))
+		Expect(t, s.Specs(), HaveLen[[]ImportSpec](0))
+	})
+
+	t.Run("AnonymousStruct", func(t *testing.T) {
+		s := NewImportSet("example.com/target", u)
+		s.Reserve("sub")
+		Expect(t, u.TypeString(typeOf("EachFieldHasComment").Underlying(), s), Equal(
+			"struct{Name string; testdata.Structure; sub1.AsSel; *sub1.AsSelPtr; "+
+				"sub1.AsIndex[any]; *sub1.AsIndexPtr[any]; sub1.AsIndexList[any, any]; "+
+				"*sub1.AsIndexListPtr[any, any]; _ any}",
+		))
+		Expect(t, s.Specs(), Equal([]ImportSpec{
+			{Path: testdata},
+			{Path: sub, Alias: "sub1"},
+		}))
+	})
+
+	t.Run("Generics", func(t *testing.T) {
+		do := u.Package(sub).Unwrap().Scope().Lookup("Do")
+		s := NewImportSet("example.com/target", nil)
+		Expect(t, u.TypeString(do.Type(), s), Equal(
+			"func[Data any, Op interface{Response() *Data}](ctx context.Context, op Op) (*Data, error)",
+		))
+		Expect(t, s.Specs(), Equal([]ImportSpec{{Path: "context"}}))
+
+		m := types.NewMap(types.Typ[types.String], types.NewSlice(typeOf("IntConstType")))
+		Expect(t, u.TypeString(m, s), Equal("map[string][]testdata.IntConstType"))
+	})
+
+	t.Run("KeepNamer", func(t *testing.T) {
+		s := NewImportSet("example.com/target", nil)
+		Expect(t, u.TypeString(typeOf("Structure"), s), Equal("testdata.Structure"))
+		// u resolves names only in TypeString, namer of s is not replaced
+		Expect(t, s.Qualifier(types.NewPackage(sub, "renamed")), Equal("renamed"))
+	})
+
+	t.Run("Untyped", func(t *testing.T) {
+		s := NewImportSet(testdata, nil)
+		Expect(t, u.TypeString(types.Typ[types.UntypedFloat], s), Equal("float64"))
+		Expect(t, u.TypeString(types.Typ[types.UnsafePointer], s), Equal("unsafe.Pointer"))
+	})
+}
@@ -0,0 +1,134 @@
+package pkgx
+
+import (
+	"go/types"
+	"strings"
+)
+
+// Element is an object of pkgx model with its owning package. TypeName is set
+// for a typename, and with Function for its method or with Field for its struct
+// field. Constant or Function is set alone for package level constant or
+// function.
+type Element struct {
+	Package  Package
+	TypeName *TypeName
+	Constant *Constant
+	Function *Function
+	Field    *types.Var
+}
+
+// Name returns name of element, method and field are prefixed with typename
+// like `Type.Method`
+func (e *Element) Name() string {
+	switch {
+	case e.Field != nil:
+		return e.TypeName.Name() + "." + e.Field.Name()
+	case e.TypeName != nil && e.Function != nil:
+		return e.TypeName.Name() + "." + e.Function.Name()
+	case e.TypeName != nil:
+		return e.TypeName.Name()
+	case e.Function != nil:
+		return e.Function.Name()
+	case e.Constant != nil:
+		return e.Constant.Name()
+	}
+	return ""
+}
+
+// Object returns types.Object of element
+func (e *Element) Object() types.Object {
+	switch {
+	case e.Field != nil:
+		return e.Field
+	case e.Function != nil:
+		return e.Function.Exposer()
+	case e.TypeName != nil:
+		return e.TypeName.Exposer()
+	case e.Constant != nil:
+		return e.Constant.Exposer()
+	}
+	return nil
+}
+
+// Doc returns documents of element
+func (e *Element) Doc() []string {
+	switch {
+	case e.Field != nil:
+		return e.TypeName.GetFieldDocByName(e.Field.Name())
+	case e.Function != nil:
+		return e.Function.Doc()
+	case e.TypeName != nil:
+		return e.TypeName.Doc()
+	case e.Constant != nil:
+		return e.Constant.Doc()
+	}
+	return nil
+}
+
+// Lookup locates element by qualified name, such as `github.com/x/y.Type`,
+// `github.com/x/y.Type.Method` and `github.com/x/y.Type.Field`. package level
+// name is resolved in order of typename, function and constant, and member of
+// typename is resolved in order of method and field. it returns nil if package
+// is not loaded or element is not found.
+func (u *Packages) Lookup(qualified string) *Element {
+	p, name := u.splitQualified(qualified)
+	if p == nil || name == "" {
+		return nil
+	}
+
+	name, member, _ := strings.Cut(name, ".")
+	if t := p.TypeNames().ElementByName(name); t != nil {
+		e := &Element{Package: p, TypeName: t}
+		if member == "" {
+			return e
+		}
+		if e.Function = t.Method(member); e.Function != nil {
+			return e
+		}
+		if e.Field = FieldOf(t, member); e.Field != nil {
+			return e
+		}
+		return nil
+	}
+	if member != "" {
+		return nil
+	}
+	if f := p.Functions().ElementByName(name); f != nil {
+		return &Element{Package: p, Function: f}
+	}
+	if c := p.Constants().ElementByName(name); c != nil {
+		return &Element{Package: p, Constant: c}
+	}
+	return nil
+}
+
+// splitQualified splits qualified name to loaded package and the remains. the
+// package path may contain dots after last slash, like `gopkg.in/yaml.v3`, so
+// each dot is tried until a loaded package is matched.
+func (u *Packages) splitQualified(qualified string) (Package, string) {
+	slash := strings.LastIndex(qualified, "/")
+	for i := slash + 1; i < len(qualified); i++ {
+		if qualified[i] != '.' {
+			continue
+		}
+		if p := u.Package(qualified[:i]); p != nil {
+			return p, qualified[i+1:]
+		}
+	}
+	return nil, ""
+}
+
+// FieldOf returns struct field of typename t by name, it returns nil if t is
+// not a struct or the field is not declared
+func FieldOf(t *TypeName, name string) *types.Var {
+	st, ok := t.Type().Underlying().(*types.Struct)
+	if !ok {
+		return nil
+	}
+	for f := range st.Fields() {
+		if f.Name() == name {
+			return f
+		}
+	}
+	return nil
+}
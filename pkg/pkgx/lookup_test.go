@@ -0,0 +1,121 @@
+package pkgx_test
+
+import (
+	"go/types"
+	"testing"
+
+	. "github.com/xoctopus/x/testx"
+
+	. "github.com/xoctopus/pkgx/pkg/pkgx"
+)
+
+func TestPackages_Lookup(t *testing.T) {
+	t.Run("TypeName", func(t *testing.T) {
+		e := u.Lookup(testdata + ".Structure")
+		Expect(t, e, NotBeNil[*Element]())
+		Expect(t, e.Package.Path(), Equal(testdata))
+		Expect(t, e.TypeName, Equal(pkg.TypeNames().ElementByName("Structure")))
+		Expect(t, e.Name(), Equal("Structure"))
+		Expect(t, e.Object(), Equal[types.Object](e.TypeName.Exposer()))
+		Expect(t, e.Doc(), Equal(e.TypeName.Doc()))
+	})
+
+	t.Run("Method", func(t *testing.T) {
+		e := u.Lookup(testdata + ".Structure.String")
+		Expect(t, e.Function, NotBeNil[*Function]())
+		Expect(t, e.Name(), Equal("Structure.String"))
+		Expect(t, e.Object().Name(), Equal("String"))
+	})
+
+	t.Run("Field", func(t *testing.T) {
+		e := u.Lookup(testdata + ".Structure.name")
+		Expect(t, e.Field.Name(), Equal("name"))
+		Expect(t, e.Name(), Equal("Structure.name"))
+		Expect(t, e.Object(), Equal[types.Object](e.Field))
+		Expect(t, e.Doc(), Equal([]string{"name comments"}))
+	})
+
+	t.Run("FunctionAndConstant", func(t *testing.T) {
+		e := u.Lookup(testdata + ".F")
+		Expect(t, e.Function.Name(), Equal("F"))
+		Expect(t, e.TypeName, BeNil[*TypeName]())
+		Expect(t, e.Name(), Equal("F"))
+		Expect(t, e.Doc(), Equal(e.Function.Doc()))
+
+		e = u.Lookup(testdata + ".IntConstTypeValue1")
+		Expect(t, e.Constant.Value().String(), Equal("1"))
+		Expect(t, e.Name(), Equal("IntConstTypeValue1"))
+		Expect(t, e.Object(), Equal[types.Object](e.Constant.Exposer()))
+		Expect(t, e.Doc(), Equal(e.Constant.Doc()))
+
+		e = u.Lookup(sub + ".Curry")
+		Expect(t, e.Package.Path(), Equal(sub))
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		for _, name := range []string{
+			"",
+			testdata,
+			"not/loaded.Type",
+			testdata + ".Unknown",
+			testdata + ".Structure.Unknown",
+			testdata + ".Int.Field",
+			testdata + ".F.Member",
+		} {
+			Expect(t, u.Lookup(name), BeNil[*Element]())
+		}
+		Expect(t, (&Element{}).Name(), Equal(""))
+		Expect(t, (&Element{}).Object(), BeNil[types.Object]())
+		Expect(t, (&Element{}).Doc(), BeNil[[]string]())
+	})
+}
+
+func TestPackages_ElementOf(t *testing.T) {
+	structure := pkg.TypeNames().ElementByName("Structure")
+
+	t.Run("TypeName", func(t *testing.T) {
+		e := u.ElementOf(structure.Exposer())
+		Expect(t, e.TypeName, Equal(structure))
+		Expect(t, e.Package.Path(), Equal(testdata))
+		Expect(t, u.ObjectAt(structure.Node().Pos()), Equal(e))
+		Expect(t, u.ObjectAt(structure.Ident().Pos()), Equal(e))
+	})
+
+	t.Run("MethodAndField", func(t *testing.T) {
+		obj, _, _ := types.LookupFieldOrMethod(structure.Type(), true, pkg.Unwrap(), "Name")
+		e := u.ElementOf(obj)
+		Expect(t, e.Name(), Equal("Structure.Name"))
+		Expect(t, u.ObjectAt(e.Function.Node().Pos()), Equal(e))
+
+		obj, _, _ = types.LookupFieldOrMethod(structure.Type(), false, pkg.Unwrap(), "name")
+		e = u.ElementOf(obj)
+		Expect(t, e.Name(), Equal("Structure.name"))
+		Expect(t, e.Doc(), Equal([]string{"name comments"}))
+		Expect(t, u.ObjectAt(obj.Pos()), Equal(e))
+
+		// promoted field is reported by its declaring typename
+		each := pkg.TypeNames().ElementByName("EachFieldHasComment")
+		obj, _, _ = types.LookupFieldOrMethod(each.Type(), false, pkg.Unwrap(), "name")
+		Expect(t, u.ElementOf(obj).TypeName, Equal(structure))
+		obj, _, _ = types.LookupFieldOrMethod(each.Type(), false, pkg.Unwrap(), "AsSel")
+		Expect(t, u.ElementOf(obj).TypeName, Equal(each))
+	})
+
+	t.Run("FunctionAndConstant", func(t *testing.T) {
+		f := pkg.Functions().ElementByName("F")
+		Expect(t, u.ElementOf(f.Exposer()).Function, Equal(f))
+		Expect(t, u.ObjectAt(f.Ident().Pos()).Function, Equal(f))
+
+		c := pkg.Constants().ElementByName("IntConstTypeValue1")
+		Expect(t, u.ElementOf(c.Exposer()).Constant, Equal(c))
+		Expect(t, u.ObjectAt(c.Ident().Pos()).Constant, Equal(c))
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		Expect(t, u.ElementOf(nil), BeNil[*Element]())
+		Expect(t, u.ElementOf(types.Universe.Lookup("error")), BeNil[*Element]())
+		Expect(t, u.ElementOf(types.NewVar(0, types.NewPackage("not/loaded", "x"), "v", types.Typ[types.Int])), BeNil[*Element]())
+		Expect(t, u.ObjectAt(0), BeNil[*Element]())
+		Expect(t, u.ObjectAt(structure.Node().End()), BeNil[*Element]())
+	})
+}
@@ -0,0 +1,118 @@
+package pkgx
+
+import (
+	"context"
+	"slices"
+
+	internal "github.com/xoctopus/pkgx/internal/pkgx"
+)
+
+// NewPackagesMatrix loads patterns under each build context, and merges the
+// model of them. every merged element is marked by the contexts it exists in.
+// contexts identified by the same BuildContext.String are loaded once.
+func NewPackagesMatrix(ctx context.Context, contexts []BuildContext, patterns ...string) *Matrix {
+	m := &Matrix{}
+	for _, c := range contexts {
+		if m.Packages(c) != nil {
+			continue
+		}
+		m.contexts = append(m.contexts, c)
+		m.universes = append(m.universes, NewPackages(CtxBuild.With(ctx, c), patterns...))
+	}
+	return m
+}
+
+type Matrix struct {
+	contexts  []BuildContext
+	universes []*Packages
+}
+
+// Contexts returns build contexts of matrix
+func (m *Matrix) Contexts() []BuildContext {
+	return m.contexts
+}
+
+// Packages returns packages loaded under build context c
+func (m *Matrix) Packages(c BuildContext) *Packages {
+	for i := range m.contexts {
+		if m.contexts[i].String() == c.String() {
+			return m.universes[i]
+		}
+	}
+	return nil
+}
+
+// Directs returns sorted direct package paths of all build contexts
+func (m *Matrix) Directs() []string {
+	paths := make([]string, 0)
+	for _, u := range m.universes {
+		for path := range u.Directs {
+			if !slices.Contains(paths, path) {
+				paths = append(paths, path)
+			}
+		}
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+// TypeNames returns merged typenames in package path
+func (m *Matrix) TypeNames(path string) []*MatrixElement[*TypeName] {
+	return merge(m, path, Package.TypeNames)
+}
+
+// Constants returns merged constants in package path
+func (m *Matrix) Constants(path string) []*MatrixElement[*Constant] {
+	return merge(m, path, Package.Constants)
+}
+
+// Functions returns merged functions in package path
+func (m *Matrix) Functions(path string) []*MatrixElement[*Function] {
+	return merge(m, path, Package.Functions)
+}
+
+// MatrixElement is a merged element by name, Contexts and Elements are paired
+type MatrixElement[V any] struct {
+	Name     string
+	Contexts []BuildContext
+	Elements []V
+}
+
+// In returns the element under build context c
+func (e *MatrixElement[V]) In(c BuildContext) (v V) {
+	for i := range e.Contexts {
+		if e.Contexts[i].String() == c.String() {
+			return e.Elements[i]
+		}
+	}
+	return
+}
+
+// All reports if element exists in all build contexts of matrix
+func (e *MatrixElement[V]) All(m *Matrix) bool {
+	return len(e.Contexts) == len(m.contexts)
+}
+
+func merge[U internal.Exposer, V internal.Object[U]](m *Matrix, path string, objects func(Package) internal.Objects[U, V]) []*MatrixElement[V] {
+	elements := make([]*MatrixElement[V], 0)
+	indexes := make(map[string]int)
+
+	for i, u := range m.universes {
+		p := u.Package(path)
+		if p == nil {
+			continue
+		}
+		for v := range objects(p).Elements() {
+			idx, ok := indexes[v.Name()]
+			if !ok {
+				idx = len(elements)
+				indexes[v.Name()] = idx
+				elements = append(elements, &MatrixElement[V]{Name: v.Name()})
+			}
+			e := elements[idx]
+			e.Contexts = append(e.Contexts, m.contexts[i])
+			e.Elements = append(e.Elements, v)
+		}
+	}
+	return elements
+}
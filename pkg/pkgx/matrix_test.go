@@ -0,0 +1,72 @@
+package pkgx_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/xoctopus/x/testx"
+
+	. "github.com/xoctopus/pkgx/pkg/pkgx"
+)
+
+func TestNewPackagesMatrix(t *testing.T) {
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"go.mod":           "module example.com/matrix\n\ngo 1.25\n",
+		"consts.go":        "package matrix\n\n// Common exists in all contexts\nconst Common = 1\n",
+		"consts_linux.go":  "package matrix\n\nconst OSName = \"linux\"\n\nconst LinuxOnly = 1\n",
+		"consts_darwin.go": "package matrix\n\nconst OSName = \"darwin\"\n\nfunc DarwinOnly() {}\n",
+		"extra.go":         "//go:build extra\n\npackage matrix\n\ntype Extra struct{}\n",
+	} {
+		Expect(t, os.WriteFile(filepath.Join(root, name), []byte(content), os.ModePerm), Succeed())
+	}
+
+	var (
+		linux  = BuildContext{GOOS: "linux", GOARCH: "amd64"}
+		darwin = BuildContext{GOOS: "darwin", GOARCH: "arm64", Tags: []string{"extra"}}
+		path   = "example.com/matrix"
+	)
+
+	// same context with unordered tags is loaded once
+	linuxTagged := BuildContext{GOOS: "linux", GOARCH: "amd64", Tags: []string{"b", "a"}}
+	duplicated := BuildContext{GOOS: "linux", GOARCH: "amd64", Tags: []string{"a", "b"}}
+	contexts := []BuildContext{linux, darwin, linuxTagged, duplicated}
+
+	m := NewPackagesMatrix(CtxWorkdir.With(context.Background(), root), contexts, path)
+	Expect(t, m.Contexts(), Equal(contexts[:3]))
+	Expect(t, m.Packages(duplicated), Equal(m.Packages(linuxTagged)))
+	m = NewPackagesMatrix(CtxWorkdir.With(context.Background(), root), contexts[:2], path)
+	Expect(t, m.Contexts(), HaveLen[[]BuildContext](2))
+	Expect(t, m.Directs(), Equal([]string{path}))
+	Expect(t, m.Packages(darwin).Package(path).TypeNames().Len(), Equal(1))
+	Expect(t, m.Packages(BuildContext{GOOS: "windows"}), BeNil[*Packages]())
+
+	constants := m.Constants(path)
+	Expect(t, constants, HaveLen[[]*MatrixElement[*Constant]](3))
+
+	Expect(t, constants[0].Name, Equal("Common"))
+	Expect(t, constants[0].All(m), BeTrue())
+	Expect(t, constants[0].In(darwin).Doc(), Equal([]string{"Common exists in all contexts"}))
+
+	Expect(t, constants[1].Name, Equal("OSName"))
+	Expect(t, constants[1].All(m), BeTrue())
+	Expect(t, constants[1].In(linux).Value().ExactString(), Equal(`"linux"`))
+	Expect(t, constants[1].In(darwin).Value().ExactString(), Equal(`"darwin"`))
+
+	Expect(t, constants[2].Name, Equal("LinuxOnly"))
+	Expect(t, constants[2].Contexts, Equal([]BuildContext{linux}))
+	Expect(t, constants[2].In(darwin), BeNil[*Constant]())
+
+	functions := m.Functions(path)
+	Expect(t, functions, HaveLen[[]*MatrixElement[*Function]](1))
+	Expect(t, functions[0].Contexts, Equal([]BuildContext{darwin}))
+
+	typenames := m.TypeNames(path)
+	Expect(t, typenames, HaveLen[[]*MatrixElement[*TypeName]](1))
+	Expect(t, typenames[0].Name, Equal("Extra"))
+	Expect(t, typenames[0].Contexts[0].String(), Equal("darwin/arm64,extra"))
+
+	Expect(t, m.Constants("not/loaded"), HaveLen[[]*MatrixElement[*Constant]](0))
+}
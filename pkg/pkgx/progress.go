@@ -0,0 +1,55 @@
+package pkgx
+
+import (
+	"context"
+	"sync"
+
+	"github.com/xoctopus/x/contextx"
+)
+
+// Stage is a stage of loading packages
+type Stage string
+
+const (
+	// StageLoaded reports a package is loaded and registered
+	StageLoaded Stage = "loaded"
+	// StageIndexed reports a direct package is hashed and its model is built
+	StageIndexed Stage = "indexed"
+)
+
+// Progress describes a package finished in a stage. Done counts finished
+// packages in the stage, including current one, and Total is the number of
+// packages the stage will process.
+type Progress struct {
+	Stage   Stage
+	Package string
+	Done    int
+	Total   int
+}
+
+// CtxProgress is a hook receiving loading progress. it is called sequentially
+// even if packages are indexed concurrently.
+var CtxProgress = contextx.NewT[func(Progress)](contextx.WithDefault[func(Progress)](nil))
+
+// progress reports packages finished in a stage to hook carried by context
+type progress struct {
+	mu    sync.Mutex
+	hook  func(Progress)
+	stage Stage
+	done  int
+	total int
+}
+
+func newProgress(ctx context.Context, stage Stage, total int) *progress {
+	return &progress{hook: CtxProgress.MustFrom(ctx), stage: stage, total: total}
+}
+
+func (p *progress) report(path string) {
+	if p.hook == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	p.hook(Progress{Stage: p.stage, Package: path, Done: p.done, Total: p.total})
+}
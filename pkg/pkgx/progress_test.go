@@ -0,0 +1,71 @@
+package pkgx_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/xoctopus/x/contextx"
+	. "github.com/xoctopus/x/testx"
+
+	. "github.com/xoctopus/pkgx/pkg/pkgx"
+)
+
+func TestLoadPackages(t *testing.T) {
+	t.Run("Progress", func(t *testing.T) {
+		reported := make(map[Stage][]Progress)
+		ctx := contextx.Compose(
+			CtxWorkdir.Carry(dir),
+			CtxProgress.Carry(func(p Progress) {
+				reported[p.Stage] = append(reported[p.Stage], p)
+			}),
+		)(context.Background())
+
+		x, err := LoadPackages(ctx, testdata)
+		Expect(t, err, Succeed())
+
+		total := 0
+		for range x.Packages {
+			total++
+		}
+		loaded := reported[StageLoaded]
+		Expect(t, len(loaded), Equal(total))
+		for i, p := range loaded {
+			Expect(t, p.Done, Equal(i+1))
+			Expect(t, p.Total, Equal(len(loaded)))
+		}
+		Expect(t, loaded[len(loaded)-1].Package, Equal(testdata))
+
+		indexed := reported[StageIndexed]
+		Expect(t, indexed, HaveLen[[]Progress](2))
+		Expect(t, indexed[1].Done, Equal(2))
+		Expect(t, indexed[1].Total, Equal(2))
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(CtxWorkdir.With(context.Background(), dir))
+		cancel()
+
+		_, err := LoadPackages(ctx, testdata)
+		Expect(t, errors.Is(err, context.Canceled), BeTrue())
+		ExpectPanic[error](t, func() { NewPackages(ctx, testdata) })
+	})
+
+	t.Run("CanceledWhileIndexing", func(t *testing.T) {
+		for _, stage := range []Stage{StageLoaded, StageIndexed} {
+			ctx, cancel := context.WithCancel(context.Background())
+			ctx = contextx.Compose(
+				CtxWorkdir.Carry(dir),
+				CtxWorkers.Carry(1),
+				CtxProgress.Carry(func(p Progress) {
+					if p.Stage == stage {
+						cancel()
+					}
+				}),
+			)(ctx)
+
+			_, err := LoadPackages(ctx, testdata)
+			Expect(t, errors.Is(err, context.Canceled), BeTrue())
+		}
+	})
+}
@@ -0,0 +1,209 @@
+package pkgx
+
+import (
+	"go/types"
+	"iter"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/xoctopus/x/misc/must"
+
+	internal "github.com/xoctopus/pkgx/internal/pkgx"
+)
+
+// Filter reports if an element is selected
+type Filter func(*Element) bool
+
+// AllOf selects elements matched by all filters
+func AllOf(filters ...Filter) Filter {
+	return func(e *Element) bool {
+		for _, f := range filters {
+			if !f(e) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// AnyOf selects elements matched by any of filters
+func AnyOf(filters ...Filter) Filter {
+	return func(e *Element) bool {
+		for _, f := range filters {
+			if f(e) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// NoneOf selects elements matched by none of filters
+func NoneOf(filters ...Filter) Filter {
+	f := AnyOf(filters...)
+	return func(e *Element) bool {
+		return !f(e)
+	}
+}
+
+// Exported selects exported elements
+func Exported() Filter {
+	return func(e *Element) bool {
+		o := e.Object()
+		return o != nil && o.Exported()
+	}
+}
+
+// Kind selects elements whose underlying type kind is one of kinds, kinds are
+// reported by KindOf, such as `struct`, `interface` and `basic`
+func Kind(kinds ...string) Filter {
+	return func(e *Element) bool {
+		o := e.Object()
+		return o != nil && slices.Contains(kinds, KindOf(o.Type()))
+	}
+}
+
+// Annotated selects elements whose document has tag `+key`. if values are
+// given, the tag must have one of them, such as `+key=value`
+func Annotated(key string, values ...string) Filter {
+	return func(e *Element) bool {
+		_, tags := internal.ParseLines(e.Doc())
+		vs, ok := tags[key]
+		if !ok {
+			return false
+		}
+		if len(values) == 0 {
+			return true
+		}
+		for _, v := range vs {
+			if slices.Contains(values, v) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Implements selects typenames which implement interface t by value or by
+// pointer. it panics if t is not an interface
+func Implements(t types.Type) Filter {
+	iface, ok := t.Underlying().(*types.Interface)
+	must.BeTrueF(ok, "expect an interface type, but got `%s`", t)
+
+	return func(e *Element) bool {
+		if e.TypeName == nil || e.Function != nil || e.Field != nil {
+			return false
+		}
+		typ := e.TypeName.Type()
+		if types.IsInterface(typ) {
+			return types.AssignableTo(typ, iface)
+		}
+		return types.Implements(typ, iface) || types.Implements(types.NewPointer(typ), iface)
+	}
+}
+
+// InFile selects elements declared in files matched by glob pattern. the
+// pattern is matched with trailing path elements of filename, so `*.go`
+// matches base name and `sub/*.go` matches files under dir named sub. it
+// panics if pattern is malformed
+func InFile(pattern string) Filter {
+	pattern = filepath.Clean(pattern)
+	_, err := filepath.Match(pattern, "")
+	must.NoErrorF(err, "invalid file pattern: %s", pattern)
+
+	elems := strings.Count(pattern, string(filepath.Separator)) + 1
+	return func(e *Element) bool {
+		o := e.Object()
+		if o == nil {
+			return false
+		}
+		filename := e.Package.Position(o.Pos()).Filename
+		if !filepath.IsAbs(pattern) {
+			parts := strings.Split(filename, string(filepath.Separator))
+			filename = filepath.Join(parts[max(len(parts)-elems, 0):]...)
+		}
+		matched, _ := filepath.Match(pattern, filename)
+		return matched
+	}
+}
+
+// NameMatches selects elements whose name matches regular expression expr. it
+// panics if expr is malformed
+func NameMatches(expr string) Filter {
+	re := regexp.MustCompile(expr)
+	return func(e *Element) bool {
+		o := e.Object()
+		return o != nil && re.MatchString(o.Name())
+	}
+}
+
+// Select returns elements of objects in package p which matched by all filters
+// in declaration order. objects are TypeNames, Functions or Constants of p
+func Select[U internal.Exposer, V internal.Object[U]](p Package, objects internal.Objects[U, V], filters ...Filter) iter.Seq[V] {
+	f := AllOf(filters...)
+	return func(yield func(V) bool) {
+		for v := range objects.Elements() {
+			if f(elementOf(p, v)) && !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Query returns package level elements of p which matched by all filters,
+// typenames are yielded first, and then functions and constants.
+func Query(p Package, filters ...Filter) iter.Seq[*Element] {
+	f := AllOf(filters...)
+	return func(yield func(*Element) bool) {
+		for _, seq := range []iter.Seq[*Element]{
+			elements(p, p.TypeNames()),
+			elements(p, p.Functions()),
+			elements(p, p.Constants()),
+		} {
+			for e := range seq {
+				if f(e) && !yield(e) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Query returns package level elements of direct packages which matched by all
+// filters, packages are ordered by path.
+func (u *Packages) Query(filters ...Filter) iter.Seq[*Element] {
+	return func(yield func(*Element) bool) {
+		for _, path := range slices.Sorted(u.Directs) {
+			for e := range Query(u.Package(path), filters...) {
+				if !yield(e) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func elements[U internal.Exposer, V internal.Object[U]](p Package, objects internal.Objects[U, V]) iter.Seq[*Element] {
+	return func(yield func(*Element) bool) {
+		for v := range objects.Elements() {
+			if !yield(elementOf(p, v)) {
+				return
+			}
+		}
+	}
+}
+
+func elementOf(p Package, v any) *Element {
+	e := &Element{Package: p}
+	switch x := v.(type) {
+	case *TypeName:
+		e.TypeName = x
+	case *Function:
+		e.Function = x
+	case *Constant:
+		e.Constant = x
+	}
+	return e
+}
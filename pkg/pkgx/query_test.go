@@ -0,0 +1,90 @@
+package pkgx_test
+
+import (
+	"go/types"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	. "github.com/xoctopus/x/testx"
+
+	. "github.com/xoctopus/pkgx/pkg/pkgx"
+)
+
+func names(seq func(func(*Element) bool)) []string {
+	var s []string
+	for e := range seq {
+		s = append(s, e.Package.Name()+"."+e.Name())
+	}
+	return s
+}
+
+func TestQuery(t *testing.T) {
+	stringer := types.NewInterfaceType([]*types.Func{
+		types.NewFunc(0, nil, "String", types.NewSignatureType(
+			nil, nil, nil, nil,
+			types.NewTuple(types.NewVar(0, nil, "", types.Typ[types.String])),
+			false,
+		)),
+	}, nil).Complete()
+
+	t.Run("Package", func(t *testing.T) {
+		Expect(t, names(Query(pkg, Kind("struct"), Exported())), Equal([]string{
+			"testdata.Structure",
+			"testdata.StructureAlias",
+			"testdata.EachFieldHasComment",
+		}))
+		Expect(t, names(Query(pkg, Annotated("tag1", "val1_2"))), Equal([]string{
+			"testdata.TypeA",
+			"testdata.TypeB",
+		}))
+		Expect(t, names(Query(pkg, Annotated("key3"))), Equal([]string{"testdata.IntConstType"}))
+		Expect(t, names(Query(pkg, Annotated("tag1", "none"))), HaveLen[[]string](0))
+		Expect(t, names(Query(pkg, InFile("functions.go"))), Equal([]string{
+			"testdata.Curry",
+			"testdata.F",
+		}))
+		Expect(t, names(Query(pkg, NameMatches(`^IntConstTypeValue\d$`))), Equal([]string{
+			"testdata.IntConstTypeValue1",
+			"testdata.IntConstTypeValue2",
+			"testdata.IntConstTypeValue3",
+		}))
+		Expect(t, names(Query(pkg, AnyOf(NameMatches("^F$"), NameMatches("^Int$")), NoneOf(Kind("basic")))), Equal([]string{
+			"testdata.F",
+		}))
+	})
+
+	t.Run("Packages", func(t *testing.T) {
+		Expect(t, names(u.Query(Implements(stringer))), Equal([]string{
+			"testdata.Structure",
+			"testdata.StructureAlias",
+			"testdata.EachFieldHasComment",
+			"sub.Structure",
+		}))
+		Expect(t, names(u.Query(Kind("struct"), InFile("sub/*.go"), NameMatches("^AsSel"))), Equal([]string{
+			"sub.AsSel",
+			"sub.AsSelPtr",
+		}))
+		for range u.Query() {
+			break
+		}
+	})
+
+	t.Run("Select", func(t *testing.T) {
+		selected := slices.Collect(Select(pkg, pkg.Constants(), NameMatches("^INT_STRING_ENUM_[A-Z]$")))
+		Expect(t, selected, HaveLen[[]*Constant](3))
+		Expect(t, selected[0].Name(), Equal("INT_STRING_ENUM_A"))
+
+		for range Select(pkg, pkg.TypeNames()) {
+			break
+		}
+		Expect(t, slices.Collect(Select(pkg, pkg.Functions(), Implements(stringer))), HaveLen[[]*Function](0))
+	})
+
+	t.Run("InvalidArguments", func(t *testing.T) {
+		ExpectPanic[error](t, func() { Implements(types.Typ[types.Int]) })
+		ExpectPanic[error](t, func() { InFile("[") })
+		Expect(t, names(Query(pkg, InFile(filepath.Join(dir, "functions.go")))), HaveLen[[]string](2))
+		ExpectPanic[string](t, func() { NameMatches("(") })
+	})
+}
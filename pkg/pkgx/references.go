@@ -0,0 +1,96 @@
+package pkgx
+
+import (
+	"cmp"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"slices"
+	"sync"
+)
+
+// Reference is a use site of an object
+type Reference struct {
+	Package  Package
+	Ident    *ast.Ident
+	Position token.Position
+	// Enclosing is the function or method whose declaration contains the use
+	// site, it is nil if the use site is at package level
+	Enclosing *Element
+}
+
+// References returns use sites of obj in all loaded packages which have type
+// info. obj can be a typename, function, method, constant, variable or field,
+// and uses of its instances are included if obj is generic. references are
+// ordered by package path and position.
+func (u *Packages) References(obj types.Object) []*Reference {
+	refs := make([]*Reference, 0)
+	if obj == nil {
+		return refs
+	}
+	paths := u.packages.Keys()
+	slices.Sort(paths)
+	for _, path := range paths {
+		refs = append(refs, u.Package(path).References(obj)...)
+	}
+	return refs
+}
+
+func (x *xpkg) References(obj types.Object) []*Reference {
+	refs := make([]*Reference, 0)
+	for _, ident := range x.uses()[origin(obj)] {
+		refs = append(refs, &Reference{
+			Package:   x,
+			Ident:     ident,
+			Position:  x.Position(ident.Pos()),
+			Enclosing: x.enclosingFunc(ident.Pos()),
+		})
+	}
+	return refs
+}
+
+// newUses returns a lazy index of identifiers by used objects, identifiers are
+// ordered by position
+func newUses(x *xpkg) func() map[types.Object][]*ast.Ident {
+	return sync.OnceValue(func() map[types.Object][]*ast.Ident {
+		uses := make(map[types.Object][]*ast.Ident)
+		if x.p.TypesInfo == nil {
+			return uses
+		}
+		for ident, obj := range x.p.TypesInfo.Uses {
+			obj = origin(obj)
+			uses[obj] = append(uses[obj], ident)
+		}
+		for _, idents := range uses {
+			slices.SortFunc(idents, func(a, b *ast.Ident) int {
+				return cmp.Compare(a.Pos(), b.Pos())
+			})
+		}
+		return uses
+	})
+}
+
+// enclosingFunc returns element of function declaration contains pos
+func (x *xpkg) enclosingFunc(pos token.Pos) *Element {
+	f := x.fileOf(pos)
+	if f == nil {
+		return nil
+	}
+	for _, decl := range f.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Pos() <= pos && pos < fn.End() {
+			return x.ElementOf(x.p.TypesInfo.Defs[fn.Name])
+		}
+	}
+	return nil
+}
+
+// origin returns generic origin of instantiated function or field
+func origin(obj types.Object) types.Object {
+	switch o := obj.(type) {
+	case *types.Func:
+		return o.Origin()
+	case *types.Var:
+		return o.Origin()
+	}
+	return obj
+}
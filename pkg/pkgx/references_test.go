@@ -0,0 +1,69 @@
+package pkgx_test
+
+import (
+	"fmt"
+	"go/types"
+	"path/filepath"
+	"testing"
+
+	. "github.com/xoctopus/x/testx"
+
+	. "github.com/xoctopus/pkgx/pkg/pkgx"
+)
+
+func TestPackages_References(t *testing.T) {
+	sites := func(refs []*Reference) []string {
+		s := make([]string, 0, len(refs))
+		for _, r := range refs {
+			enclosing := ""
+			if r.Enclosing != nil {
+				enclosing = r.Enclosing.Name()
+			}
+			s = append(s, fmt.Sprintf("%s:%d:%d %s",
+				filepath.Base(r.Position.Filename), r.Position.Line, r.Position.Column, enclosing,
+			))
+		}
+		return s
+	}
+
+	t.Run("Method", func(t *testing.T) {
+		e := u.Lookup(testdata + ".Structure.Name")
+		refs := u.References(e.Object())
+		Expect(t, sites(refs), Equal([]string{
+			"functions.go:34:21 F",
+			"functions.go:35:21 F",
+			"functions.go:37:8 F",
+		}))
+		Expect(t, refs[0].Package.Path(), Equal(testdata))
+		Expect(t, refs[0].Ident.Name, Equal("Name"))
+	})
+
+	t.Run("TypeName", func(t *testing.T) {
+		e := u.Lookup(testdata + ".Structure")
+		Expect(t, sites(u.References(e.Object())), Equal([]string{
+			"functions.go:34:8 F",
+			"functions.go:35:10 F",
+			"functions.go:36:11 F",
+			"documents.go:68:23 ",
+			"documents.go:70:10 Structure.Name",
+			"documents.go:74:9 Structure.String",
+			"documents.go:97:2 ",
+		}))
+	})
+
+	t.Run("OtherPackage", func(t *testing.T) {
+		refs := u.References(u.Lookup(sub + ".Curry").Object())
+		Expect(t, sites(refs), Equal([]string{"functions.go:47:6 F"}))
+		Expect(t, refs[0].Package.Path(), Equal(testdata))
+	})
+
+	t.Run("Generic", func(t *testing.T) {
+		e := u.Lookup(sub + ".AsIndex")
+		Expect(t, sites(u.References(e.Object())), Equal([]string{"documents.go:103:6 "}))
+	})
+
+	t.Run("NoReference", func(t *testing.T) {
+		Expect(t, u.References(nil), HaveLen[[]*Reference](0))
+		Expect(t, u.References(types.NewVar(0, nil, "v", types.Typ[types.Int])), HaveLen[[]*Reference](0))
+	})
+}
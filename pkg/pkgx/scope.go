@@ -0,0 +1,83 @@
+package pkgx
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// EnclosingDecl returns the innermost element whose declaration contains pos
+// and the ast path from the innermost node to the file. the element is a
+// struct field, typename, function, method or constant, it is nil if pos is not
+// inside any of them. nil path is returned if pos is not in files of x.
+func (x *xpkg) EnclosingDecl(pos token.Pos) (*Element, []ast.Node) {
+	f := x.fileOf(pos)
+	if f == nil {
+		return nil, nil
+	}
+
+	path, _ := astutil.PathEnclosingInterval(f, pos, pos)
+	for _, node := range path {
+		var ident *ast.Ident
+		switch n := node.(type) {
+		case *ast.Field:
+			if len(n.Names) > 0 {
+				ident = n.Names[0]
+			}
+		case *ast.ValueSpec:
+			ident = n.Names[0]
+		case *ast.TypeSpec:
+			ident = n.Name
+		case *ast.FuncDecl:
+			ident = n.Name
+		}
+		if ident == nil {
+			continue
+		}
+		if e := x.ElementOf(x.p.TypesInfo.Defs[ident]); e != nil {
+			return e, path
+		}
+	}
+	return nil, path
+}
+
+// ScopeAt returns objects visible at pos, from the innermost scope to package
+// scope. shadowed and universe objects are excluded, and local objects are
+// visible only after they are declared.
+func (x *xpkg) ScopeAt(pos token.Pos) []types.Object {
+	if x.p.Types == nil || x.fileOf(pos) == nil {
+		return nil
+	}
+
+	inner := x.p.Types.Scope().Innermost(pos)
+	if inner == nil {
+		return nil
+	}
+
+	objects := make([]types.Object, 0)
+	visited := make(map[string]struct{})
+	for s := inner; s != nil && s != types.Universe; s = s.Parent() {
+		for _, name := range s.Names() {
+			if _, ok := visited[name]; ok {
+				continue
+			}
+			if scope, o := inner.LookupParent(name, pos); scope == s && o != nil {
+				visited[name] = struct{}{}
+				objects = append(objects, o)
+			}
+		}
+	}
+	return objects
+}
+
+// fileOf returns syntax file contains pos
+func (x *xpkg) fileOf(pos token.Pos) *ast.File {
+	for _, f := range x.p.Syntax {
+		if f.FileStart <= pos && pos <= f.FileEnd {
+			return f
+		}
+	}
+	return nil
+}
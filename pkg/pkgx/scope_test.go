@@ -0,0 +1,96 @@
+package pkgx_test
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"testing"
+
+	. "github.com/xoctopus/x/testx"
+
+	. "github.com/xoctopus/pkgx/pkg/pkgx"
+)
+
+// posOf returns pos of line and column in file of package p
+func posOf(p Package, filename string, line, column int) token.Pos {
+	for _, f := range p.Files() {
+		tf := p.FileSet().File(f.Pos())
+		if filepath.Base(tf.Name()) == filename {
+			return tf.LineStart(line) + token.Pos(column-1)
+		}
+	}
+	return token.NoPos
+}
+
+func TestPackage_EnclosingDecl(t *testing.T) {
+	t.Run("Function", func(t *testing.T) {
+		// `_ = s.Name()` in F
+		e, path := pkg.EnclosingDecl(posOf(pkg, "functions.go", 37, 8))
+		Expect(t, e.Name(), Equal("F"))
+		Expect(t, path[0].(*ast.Ident).Name, Equal("Name"))
+		_, ok := path[len(path)-1].(*ast.File)
+		Expect(t, ok, BeTrue())
+
+		// call func literal in Curry
+		e, _ = pkg.EnclosingDecl(posOf(pkg, "functions.go", 16, 11))
+		Expect(t, e.Name(), Equal("Curry"))
+	})
+
+	t.Run("Method", func(t *testing.T) {
+		e, _ := pkg.EnclosingDecl(posOf(pkg, "documents.go", 71, 2))
+		Expect(t, e.Name(), Equal("Structure.Name"))
+	})
+
+	t.Run("TypeNameAndField", func(t *testing.T) {
+		e, _ := pkg.EnclosingDecl(posOf(pkg, "documents.go", 63, 10))
+		Expect(t, e.Name(), Equal("Structure.name"))
+		e, _ = pkg.EnclosingDecl(posOf(pkg, "documents.go", 65, 1))
+		Expect(t, e.Name(), Equal("Structure"))
+	})
+
+	t.Run("Constant", func(t *testing.T) {
+		e, _ := pkg.EnclosingDecl(posOf(pkg, "documents.go", 21, 40))
+		Expect(t, e.Name(), Equal("IntConstTypeValue1"))
+	})
+
+	t.Run("Outside", func(t *testing.T) {
+		// package level variable `ff`
+		e, path := pkg.EnclosingDecl(posOf(pkg, "functions.go", 10, 5))
+		Expect(t, e, BeNil[*Element]())
+		Expect(t, len(path), BeGt(0))
+
+		e, path = pkg.EnclosingDecl(token.NoPos)
+		Expect(t, e, BeNil[*Element]())
+		Expect(t, path, BeNil[[]ast.Node]())
+	})
+}
+
+func TestPackage_ScopeAt(t *testing.T) {
+	visible := func(objects []types.Object) map[string]types.Object {
+		m := make(map[string]types.Object)
+		for _, o := range objects {
+			m[o.Name()] = o
+		}
+		return m
+	}
+
+	// before `s := new(Structure)` in F
+	objects := visible(pkg.ScopeAt(posOf(pkg, "functions.go", 36, 2)))
+	Expect(t, objects, HaveKey[string, types.Object, map[string]types.Object]("f"))
+	Expect(t, objects, HaveKey[string, types.Object, map[string]types.Object]("ff"))
+	Expect(t, objects, HaveKey[string, types.Object, map[string]types.Object]("Structure"))
+	Expect(t, objects, HaveKey[string, types.Object, map[string]types.Object]("sub"))
+	_, ok := objects["s"]
+	Expect(t, ok, BeFalse())
+	_, ok = objects["int"]
+	Expect(t, ok, BeFalse())
+
+	// after `s := new(Structure)`, local objects come before package objects
+	scope := pkg.ScopeAt(posOf(pkg, "functions.go", 37, 2))
+	Expect(t, scope[0].Name(), Equal("f"))
+	Expect(t, visible(scope), HaveKey[string, types.Object, map[string]types.Object]("s"))
+	Expect(t, visible(scope)["F"].Parent(), Equal(pkg.Unwrap().Scope()))
+
+	Expect(t, pkg.ScopeAt(token.NoPos), BeNil[[]types.Object]())
+}
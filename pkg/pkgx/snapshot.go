@@ -0,0 +1,290 @@
+package pkgx
+
+import (
+	"go/token"
+	"go/types"
+	"maps"
+	"slices"
+
+	internal "github.com/xoctopus/pkgx/internal/pkgx"
+)
+
+// SnapshotVersion is the format version of Snapshot, it should be increased
+// when the layout of snapshot changed
+const SnapshotVersion = 3
+
+// Snapshot is a serializable model of loaded packages. it contains no go/types
+// or go/ast values, so it can be cached or exported and be consumed without
+// loading packages again. in workspace mode, Workspace is filename of go.work
+// and WorkspaceSum is the hash of go.work and go.work.sum. Listed records
+// package ids that wildcard patterns expanded to
+type Snapshot struct {
+	Version      int                `json:"version"`
+	GoVersion    string             `json:"goVersion,omitempty"`
+	Env          []string           `json:"env,omitempty"`
+	Workdir      string             `json:"workdir,omitempty"`
+	Tests        bool               `json:"tests,omitempty"`
+	Build        BuildContext       `json:"build"`
+	Patterns     []string           `json:"patterns,omitempty"`
+	Listed       []string           `json:"listed,omitempty"`
+	Workspace    string             `json:"workspace,omitempty"`
+	WorkspaceSum string             `json:"workspaceSum,omitempty"`
+	Modules      []*ModuleSnapshot  `json:"modules,omitempty"`
+	Packages     []*PackageSnapshot `json:"packages"`
+}
+
+// Package returns package snapshot by package path
+func (s *Snapshot) Package(path string) *PackageSnapshot {
+	for _, p := range s.Packages {
+		if p.Path == path {
+			return p
+		}
+	}
+	return nil
+}
+
+type ModuleSnapshot struct {
+	Path      string `json:"path"`
+	Version   string `json:"version,omitempty"`
+	Dir       string `json:"dir,omitempty"`
+	GoVersion string `json:"goVersion,omitempty"`
+	Main      bool   `json:"main,omitempty"`
+	// Sum is the hash of go.mod and go.sum
+	Sum string `json:"sum,omitempty"`
+}
+
+type PackageSnapshot struct {
+	Path      string              `json:"path"`
+	ID        string              `json:"id"`
+	Name      string              `json:"name"`
+	Module    string              `json:"module,omitempty"`
+	Dir       string              `json:"dir,omitempty"`
+	Hash      string              `json:"hash,omitempty"`
+	Direct    bool                `json:"direct,omitempty"`
+	Doc       []string            `json:"doc,omitempty"`
+	TypeNames []*TypeNameSnapshot `json:"typenames,omitempty"`
+	Constants []*ConstantSnapshot `json:"constants,omitempty"`
+	Functions []*FunctionSnapshot `json:"functions,omitempty"`
+}
+
+// TypeName returns typename snapshot by name
+func (p *PackageSnapshot) TypeName(name string) *TypeNameSnapshot {
+	for _, t := range p.TypeNames {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// Constant returns constant snapshot by name
+func (p *PackageSnapshot) Constant(name string) *ConstantSnapshot {
+	for _, c := range p.Constants {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// Function returns function snapshot by name
+func (p *PackageSnapshot) Function(name string) *FunctionSnapshot {
+	for _, f := range p.Functions {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+type TypeNameSnapshot struct {
+	Name       string              `json:"name"`
+	Kind       string              `json:"kind"`
+	Alias      bool                `json:"alias,omitempty"`
+	Underlying string              `json:"underlying"`
+	Doc        []string            `json:"doc,omitempty"`
+	Position   Position            `json:"position"`
+	Fields     []*FieldSnapshot    `json:"fields,omitempty"`
+	Methods    []*FunctionSnapshot `json:"methods,omitempty"`
+}
+
+type FieldSnapshot struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Embedded bool     `json:"embedded,omitempty"`
+	Tag      string   `json:"tag,omitempty"`
+	Doc      []string `json:"doc,omitempty"`
+	Position Position `json:"position"`
+}
+
+type ConstantSnapshot struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Value    string   `json:"value"`
+	Doc      []string `json:"doc,omitempty"`
+	Position Position `json:"position"`
+}
+
+type FunctionSnapshot struct {
+	Name      string   `json:"name"`
+	Signature string   `json:"signature"`
+	PtrRecv   bool     `json:"ptrRecv,omitempty"`
+	Doc       []string `json:"doc,omitempty"`
+	Position  Position `json:"position"`
+}
+
+// Position is a serializable token.Position
+type Position struct {
+	Filename string `json:"filename,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Offset   int    `json:"offset,omitempty"`
+}
+
+func PositionOf(p token.Position) Position {
+	return Position{
+		Filename: p.Filename,
+		Line:     p.Line,
+		Column:   p.Column,
+		Offset:   p.Offset,
+	}
+}
+
+// Snapshot takes a snapshot of direct packages
+func (u *Packages) Snapshot() *Snapshot {
+	return u.snapshotOf(slices.Sorted(u.Directs))
+}
+
+func (u *Packages) snapshotOf(paths []string) *Snapshot {
+	s := &Snapshot{Version: SnapshotVersion}
+
+	modules := make(map[string]*ModuleSnapshot)
+	for _, path := range paths {
+		p := u.Package(path)
+		if p == nil {
+			continue
+		}
+		s.Packages = append(s.Packages, u.snapshot(p, u.directs.Exists(path)))
+		if m := p.GoModule(); m != nil && modules[m.Path] == nil {
+			modules[m.Path] = &ModuleSnapshot{
+				Path:      m.Path,
+				Version:   m.Version,
+				Dir:       m.Dir,
+				GoVersion: m.GoVersion,
+				Main:      m.Main,
+				Sum:       internal.HashFiles(m.Dir, "go.mod", "go.sum"),
+			}
+		}
+	}
+
+	for _, path := range slices.Sorted(maps.Keys(modules)) {
+		s.Modules = append(s.Modules, modules[path])
+	}
+	return s
+}
+
+func (u *Packages) snapshot(p Package, direct bool) *PackageSnapshot {
+	s := &PackageSnapshot{
+		Path:   p.Path(),
+		ID:     p.ID(),
+		Name:   p.Name(),
+		Dir:    p.SourceDir(),
+		Direct: direct,
+		Doc:    p.PackageDoc(),
+	}
+	if m := p.GoModule(); m != nil {
+		s.Module = m.Path
+		if sum := u.ModuleSum(m.Path); sum != nil {
+			s.Hash = sum.Hash(p.ID())
+		}
+	}
+
+	for t := range p.TypeNames().Elements() {
+		s.TypeNames = append(s.TypeNames, snapshotTypeName(p, t))
+	}
+	for c := range p.Constants().Elements() {
+		s.Constants = append(s.Constants, &ConstantSnapshot{
+			Name:     c.Name(),
+			Type:     types.TypeString(c.Type(), nil),
+			Value:    c.Value().ExactString(),
+			Doc:      c.Doc(),
+			Position: PositionOf(p.Position(c.Ident().Pos())),
+		})
+	}
+	for f := range p.Functions().Elements() {
+		s.Functions = append(s.Functions, snapshotFunction(p, f))
+	}
+	return s
+}
+
+func snapshotTypeName(p Package, t *TypeName) *TypeNameSnapshot {
+	s := &TypeNameSnapshot{
+		Name:       t.Name(),
+		Kind:       KindOf(t.Type()),
+		Alias:      t.Exposer().IsAlias(),
+		Underlying: types.TypeString(t.Type().Underlying(), nil),
+		Doc:        t.Doc(),
+		Position:   PositionOf(p.Position(t.Ident().Pos())),
+	}
+
+	if st, ok := t.Type().Underlying().(*types.Struct); ok {
+		for i := range st.NumFields() {
+			f := st.Field(i)
+			s.Fields = append(s.Fields, &FieldSnapshot{
+				Name:     f.Name(),
+				Type:     types.TypeString(f.Type(), nil),
+				Embedded: f.Embedded(),
+				Tag:      st.Tag(i),
+				Doc:      t.GetFieldDocByName(f.Name()),
+				Position: PositionOf(p.Position(f.Pos())),
+			})
+		}
+	}
+
+	methods := t.Methods().Keys()
+	slices.Sort(methods)
+	for _, name := range methods {
+		s.Methods = append(s.Methods, snapshotFunction(p, t.Method(name)))
+	}
+	return s
+}
+
+func snapshotFunction(p Package, f *Function) *FunctionSnapshot {
+	return &FunctionSnapshot{
+		Name:      f.Name(),
+		Signature: types.TypeString(f.Type(), nil),
+		PtrRecv:   f.PtrRecv(),
+		Doc:       f.Doc(),
+		Position:  PositionOf(p.Position(f.Ident().Pos())),
+	}
+}
+
+// KindOf returns kind name of type's underlying, such as `struct`, `interface`,
+// `basic`, `signature`, `map`, `slice`, `array`, `chan` and `pointer`
+func KindOf(t types.Type) string {
+	if t == nil {
+		return ""
+	}
+	switch t.Underlying().(type) {
+	case *types.Basic:
+		return "basic"
+	case *types.Struct:
+		return "struct"
+	case *types.Interface:
+		return "interface"
+	case *types.Signature:
+		return "signature"
+	case *types.Map:
+		return "map"
+	case *types.Slice:
+		return "slice"
+	case *types.Array:
+		return "array"
+	case *types.Chan:
+		return "chan"
+	case *types.Pointer:
+		return "pointer"
+	default:
+		return ""
+	}
+}
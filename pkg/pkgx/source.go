@@ -0,0 +1,85 @@
+package pkgx
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	internal "github.com/xoctopus/pkgx/internal/pkgx"
+)
+
+// declOf locates declaration of o in source of package loaded without syntax,
+// and returns its documents and struct type if o is a struct typename. the
+// declaration is matched by name and line, because positions from export data
+// have no column.
+func (x *xpkg) declOf(o types.Object) ([]string, *ast.StructType) {
+	pos := x.Position(o.Pos())
+	f, fset := x.source(pos.Filename)
+	if f == nil {
+		return nil, nil
+	}
+
+	at := func(ident *ast.Ident) bool {
+		return ident.Name == o.Name() && fset.Position(ident.Pos()).Line == pos.Line
+	}
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if at(d.Name) {
+				return internal.ExtractComments(d.Doc), nil
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if at(s.Name) {
+						st, _ := s.Type.(*ast.StructType)
+						return internal.ExtractComments(d.Doc, s.Doc, s.Comment), st
+					}
+				case *ast.ValueSpec:
+					if slices.ContainsFunc(s.Names, at) {
+						return internal.ExtractComments(d.Doc, s.Doc, s.Comment), nil
+					}
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+// source parses file of package by filename at first request and caches it.
+// filename recorded in export data may be trimmed, such as `$GOROOT/src/..`,
+// so file is resolved by base name in GoFiles, and then in source dir of
+// module. it returns nil if file is not found or failed to parse.
+func (x *xpkg) source(filename string) (*ast.File, *token.FileSet) {
+	name := filepath.Base(filename)
+	parse, _ := x.sources.LoadOrStore(name, sync.OnceValues(func() (*ast.File, *token.FileSet) {
+		path := ""
+		for _, f := range x.p.GoFiles {
+			if filepath.Base(f) == name {
+				path = f
+				break
+			}
+		}
+		if path == "" {
+			if dir := x.SourceDir(); dir != "" {
+				path = filepath.Join(dir, name)
+			}
+		}
+		if path == "" {
+			return nil, nil
+		}
+
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, path, nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			return nil, nil
+		}
+		return f, fset
+	}))
+	return parse()
+}
@@ -0,0 +1,62 @@
+package pkgx
+
+import (
+	"errors"
+	"slices"
+
+	gopkg "golang.org/x/tools/go/packages"
+
+	internal "github.com/xoctopus/pkgx/internal/pkgx"
+)
+
+const SumFilename = internal.SumFilename
+
+// SumDrift describes a package whose hash differs from the record in go.xsum
+type SumDrift struct {
+	Module   string
+	Package  string
+	Recorded string
+	Current  string
+}
+
+// CheckSums compares hashes of loaded packages with go.xsum of each module
+// under entries, it returns drifted packages ordered by module and package.
+func (u *Packages) CheckSums() []SumDrift {
+	drifts := make([]SumDrift, 0)
+	for _, module := range slices.Sorted(u.Modules) {
+		s := u.ModuleSum(module)
+		if s == nil {
+			continue
+		}
+		recorded := internal.LoadSumFile(&gopkg.Module{Path: module, Dir: s.Dir()})
+		for _, id := range s.Packages() {
+			d := SumDrift{Module: module, Package: id, Current: s.Hash(id)}
+			if recorded != nil {
+				d.Recorded = recorded.Hash(id)
+			}
+			if d.Recorded != d.Current {
+				drifts = append(drifts, d)
+			}
+		}
+	}
+	return drifts
+}
+
+// SaveSums writes go.xsum for each module under entries
+func (u *Packages) SaveSums() error {
+	errs := make([]error, 0)
+	for _, module := range slices.Sorted(u.Modules) {
+		if s := u.ModuleSum(module); s != nil {
+			errs = append(errs, s.Save())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RehashSums recomputes package hashes of each module from current files. it
+// should be called after package files changed, such as code generated.
+func (u *Packages) RehashSums() {
+	for _, s := range u.sums.Range {
+		s.Rehash()
+	}
+}
@@ -0,0 +1,40 @@
+package pkgx_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/xoctopus/x/testx"
+
+	. "github.com/xoctopus/pkgx/pkg/pkgx"
+)
+
+func TestPackages_CheckSums(t *testing.T) {
+	tmp := t.TempDir()
+	Expect(t, os.CopyFS(tmp, os.DirFS(dir)), Succeed())
+	Expect(t, os.RemoveAll(filepath.Join(tmp, SumFilename)), Succeed())
+
+	x := NewPackages(CtxWorkdir.With(context.Background(), tmp), testdata)
+
+	drifts := x.CheckSums()
+	Expect(t, drifts, HaveLen[[]SumDrift](2))
+	Expect(t, drifts[0].Module, Equal(testdata))
+	Expect(t, drifts[0].Package, Equal(testdata))
+	Expect(t, drifts[0].Recorded, Equal(""))
+	Expect(t, drifts[0].Current, Equal(x.ModuleSum(testdata).Hash(testdata)))
+	Expect(t, drifts[1].Package, Equal(sub))
+
+	Expect(t, x.SaveSums(), Succeed())
+	Expect(t, x.CheckSums(), HaveLen[[]SumDrift](0))
+
+	Expect(t, os.WriteFile(
+		filepath.Join(tmp, SumFilename),
+		[]byte(testdata+" h1:changed\n"),
+		os.ModePerm,
+	), Succeed())
+	drifts = x.CheckSums()
+	Expect(t, drifts, HaveLen[[]SumDrift](2))
+	Expect(t, drifts[0].Recorded, Equal("h1:changed"))
+}
@@ -0,0 +1,101 @@
+package pkgx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// Workspace describes go workspace of go.work
+type Workspace struct {
+	// Filename is absolute filename of go.work
+	Filename string
+	// Modules are modules used by workspace, ordered by module path
+	Modules []*GoModule
+}
+
+// Patterns returns patterns matching all packages of workspace modules
+func (w *Workspace) Patterns() []string {
+	patterns := make([]string, 0, len(w.Modules))
+	for _, m := range w.Modules {
+		patterns = append(patterns, m.Path+"/...")
+	}
+	return patterns
+}
+
+// ReadWorkspace parses go.work carried by CtxWorkspace and go.mod of modules
+// used by it
+func ReadWorkspace(ctx context.Context) (*Workspace, error) {
+	filename := workspace(ctx)
+	if filename == "" {
+		return nil, errors.New("workspace is not specified")
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workspace: %w", err)
+	}
+	wf, err := modfile.ParseWork(filename, data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse workspace: %w", err)
+	}
+
+	w := &Workspace{Filename: filename}
+	for _, use := range wf.Use {
+		dir := filepath.FromSlash(use.Path)
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(filepath.Dir(filename), dir)
+		}
+		gomod := filepath.Join(dir, "go.mod")
+		data, err = os.ReadFile(gomod)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read module of workspace: %w", err)
+		}
+		path := modfile.ModulePath(data)
+		if path == "" {
+			return nil, fmt.Errorf("no module path in %s", gomod)
+		}
+		w.Modules = append(w.Modules, &GoModule{Path: path, Dir: dir, GoMod: gomod, Main: true})
+	}
+	slices.SortFunc(w.Modules, func(a, b *GoModule) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return w, nil
+}
+
+// LoadWorkspace loads all packages of modules used by go.work carried by
+// CtxWorkspace. each module is an entry module which owns its direct packages
+// and go.xsum.
+func LoadWorkspace(ctx context.Context) (*Packages, error) {
+	w, err := ReadWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return LoadPackages(ctx, w.Patterns()...)
+}
+
+// ModuleOf returns module which direct package of path belongs to, it returns
+// nil if package is not a direct package
+func (u *Packages) ModuleOf(path string) *GoModule {
+	if !u.directs.Exists(path) {
+		return nil
+	}
+	return u.Package(path).GoModule()
+}
+
+// workspace returns absolute filename of go.work carried by ctx, or empty if
+// not in workspace mode
+func workspace(ctx context.Context) string {
+	filename := CtxWorkspace.MustFrom(ctx)
+	if filename == "" || filepath.IsAbs(filename) {
+		return filename
+	}
+	filename, _ = filepath.Abs(filepath.Join(CtxWorkdir.MustFrom(ctx), filename))
+	return filename
+}
@@ -0,0 +1,108 @@
+package pkgx_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/xoctopus/x/contextx"
+	. "github.com/xoctopus/x/testx"
+	"golang.org/x/tools/txtar"
+
+	. "github.com/xoctopus/pkgx/pkg/pkgx"
+	"github.com/xoctopus/pkgx/pkg/pkgxtest"
+)
+
+var workspace = []byte(`
+-- go.work --
+go 1.22
+
+use (
+	./api
+	./svc
+)
+-- api/go.mod --
+module example.com/api
+
+go 1.22
+-- api/api.go --
+package api
+
+// Request is declared in api module
+type Request struct{}
+-- svc/go.mod --
+module example.com/svc
+
+go 1.22
+-- svc/svc.go --
+package svc
+
+import "example.com/api"
+
+// Handle handles api.Request
+func Handle(*api.Request) {}
+-- svc/internal/store/store.go --
+package store
+`)
+
+func TestLoadWorkspace(t *testing.T) {
+	root := pkgxtest.Write(t, txtar.Parse(workspace))
+	ctx := contextx.Compose(
+		CtxWorkdir.Carry(root),
+		CtxWorkspace.Carry("go.work"),
+		// -mod=mod is not allowed in workspace mode
+		CtxEnv.Carry(append(CtxEnv.MustFrom(context.Background()), "GOFLAGS=")),
+	)(context.Background())
+
+	w, err := ReadWorkspace(ctx)
+	Expect(t, err, Succeed())
+	Expect(t, w.Filename, Equal(filepath.Join(root, "go.work")))
+	Expect(t, w.Patterns(), Equal([]string{"example.com/api/...", "example.com/svc/..."}))
+	Expect(t, w.Modules[1].Dir, Equal(filepath.Join(root, "svc")))
+	Expect(t, Config(ctx).Env[len(Config(ctx).Env)-1], Equal("GOWORK="+w.Filename))
+
+	u, err := LoadWorkspace(ctx)
+	Expect(t, err, Succeed())
+	Expect(t, slices.Sorted(u.Modules), Equal([]string{"example.com/api", "example.com/svc"}))
+	Expect(t, slices.Sorted(u.Directs), Equal([]string{
+		"example.com/api",
+		"example.com/svc",
+		"example.com/svc/internal/store",
+	}))
+	Expect(t, u.ModuleOf("example.com/api").Dir, Equal(filepath.Join(root, "api")))
+	Expect(t, u.ModuleOf("example.com/svc/internal/store").Path, Equal("example.com/svc"))
+	Expect(t, u.ModuleOf("fmt"), BeNil[*GoModule]())
+
+	// each workspace module owns its go.xsum
+	Expect(t, u.ModuleSum("example.com/api").Packages(), Equal([]string{"example.com/api"}))
+	Expect(t, u.ModuleSum("example.com/svc").Packages(), Equal([]string{
+		"example.com/svc",
+		"example.com/svc/internal/store",
+	}))
+	Expect(t, u.SaveSums(), Succeed())
+	for _, m := range w.Modules {
+		_, err = os.Stat(filepath.Join(m.Dir, SumFilename))
+		Expect(t, err, Succeed())
+	}
+	Expect(t, u.CheckSums(), HaveLen[[]SumDrift](0))
+
+	t.Run("Patterns", func(t *testing.T) {
+		x, err := LoadPackages(ctx, "example.com/svc")
+		Expect(t, err, Succeed())
+		Expect(t, slices.Sorted(x.Modules), Equal([]string{"example.com/api", "example.com/svc"}))
+		Expect(t, x.ModuleOf("example.com/api").Path, Equal("example.com/api"))
+	})
+
+	t.Run("NotSpecified", func(t *testing.T) {
+		_, err := LoadWorkspace(context.Background())
+		Expect(t, err, Failed())
+	})
+
+	t.Run("InvalidModule", func(t *testing.T) {
+		Expect(t, os.Remove(filepath.Join(root, "api", "go.mod")), Succeed())
+		_, err := ReadWorkspace(ctx)
+		Expect(t, err, Failed())
+	})
+}
@@ -3,16 +3,22 @@ package pkgx
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"go/ast"
 	"go/format"
 	"go/token"
 	"go/types"
 	"maps"
+	"os"
 	"path/filepath"
 	"slices"
+	"sync"
 
 	"github.com/xoctopus/x/misc/must"
 	"github.com/xoctopus/x/syncx"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/tools/go/gcexportdata"
 	gopkg "golang.org/x/tools/go/packages"
 
 	internal "github.com/xoctopus/pkgx/internal/pkgx"
@@ -36,63 +42,221 @@ type (
 	GoModule  = gopkg.Module
 )
 
+// NewPackages loads packages matched by patterns, it panics if failed. see
+// LoadPackages
 func NewPackages(ctx context.Context, patterns ...string) *Packages {
-	u := &Packages{
-		entries:  patterns,
-		fileset:  token.NewFileSet(),
-		packages: syncx.NewXmap[string, Package](),
-		modules:  syncx.NewSet[string](),
-		directs:  syncx.NewSet[string](),
-		sums:     syncx.NewXmap[string, ModuleSum](),
-	}
+	u, err := LoadPackages(ctx, patterns...)
+	must.NoError(err)
+	return u
+}
+
+// LoadPackages loads packages matched by patterns and builds models of them.
+// loading is aborted when ctx is canceled, and progress is reported to hook
+// carried by CtxProgress.
+func LoadPackages(ctx context.Context, patterns ...string) (*Packages, error) {
+	u := newPackages(patterns, token.NewFileSet())
 	ctx = CtxFileset.With(ctx, u.fileset)
 
 	packages, err := gopkg.Load(Config(ctx), patterns...)
-	must.NoErrorF(err, "failed to load packages: %v", patterns)
+	if e := ctx.Err(); e != nil {
+		// go command may be killed by canceling, prefer error of ctx
+		err = e
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load packages: %v: %w", patterns, err)
+	}
+	if err = u.build(ctx, packages); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
 
-	var register func(p *GoPackage)
+// FromGoPackages builds Packages from packages loaded by caller, such as which
+// are loaded with custom config or shared with other x/tools programs. pkgs are
+// treated as direct packages, and they must be loaded with syntax and type info
+// in a shared token.FileSet.
+func FromGoPackages(ctx context.Context, pkgs ...*GoPackage) (*Packages, error) {
+	fileset := CtxFileset.MustFrom(ctx)
+	for _, p := range pkgs {
+		if p.Fset == nil {
+			continue
+		}
+		if fileset == nil {
+			fileset = p.Fset
+		}
+		if p.Fset != fileset {
+			return nil, fmt.Errorf("package `%s` is loaded in a different fileset", p.ID)
+		}
+	}
+	if fileset == nil {
+		fileset = token.NewFileSet()
+	}
 
-	register = func(p *GoPackage) {
-		x := newx(p)
-		x.(*xpkg).u = u
+	entries := make([]string, 0, len(pkgs))
+	for _, p := range pkgs {
+		entries = append(entries, p.PkgPath)
+	}
 
-		for _, path := range slices.Sorted(maps.Keys(p.Imports)) {
-			if _, ok := u.packages.Load(path); !ok {
-				register(p.Imports[path])
-			}
-		}
-		u.packages.Store(p.PkgPath, x)
+	u := newPackages(entries, fileset)
+	if err := u.build(ctx, pkgs); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
 
-		if p.Module != nil {
-			if u.modules.Exists(p.Module.Path) {
-				u.directs.Store(p.PkgPath)
-				u.modules.Store(p.Module.Path)
-				s, _ := u.sums.LoadOrStore(p.Module.Path, internal.NewSum(p.Module.Dir))
-				s.Add(p)
-			}
+// build checks loaded packages, registers them and their imports, and then
+// indexes direct packages. main modules are entry modules in workspace mode.
+func (u *Packages) build(ctx context.Context, packages []*GoPackage) error {
+	var errs []error
+	total := 0
+	inWorkspace := workspace(ctx) != ""
+	gopkg.Visit(packages, nil, func(p *GoPackage) {
+		total++
+		if inWorkspace && p.Module != nil && p.Module.Main {
+			u.modules.Store(p.Module.Path)
+		}
+		for _, err := range p.Errors {
+			errs = append(errs, fmt.Errorf("loaded package `%s` error: %w", p.ID, err))
 		}
+		if len(p.Syntax) > 0 && p.TypesInfo == nil {
+			errs = append(errs, fmt.Errorf("package `%s` is loaded without type info", p.ID))
+		}
+	})
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if err := completeTypes(packages); err != nil {
+		return err
 	}
 
 	for _, p := range packages {
-		must.BeTrueF(len(p.Errors) == 0, "loaded package `%s` error: %v", p.ID, p.Errors)
 		if p.Module != nil {
 			u.modules.Store(p.Module.Path)
 		}
 		u.directs.Store(p.PkgPath)
 	}
 
+	loaded := newProgress(ctx, StageLoaded, total)
 	for _, p := range packages {
-		register(p)
+		u.register(p, loaded)
 	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return u.index(ctx)
+}
+
+// completeTypes completes types of dependencies loaded without syntax. the
+// importer only declares objects referenced by its importers, so types of
+// indirect dependencies may be incomplete. the rest objects are read from
+// export data into the same package to keep identities of objects.
+func completeTypes(packages []*GoPackage) error {
+	imports := make(map[string]*types.Package)
+	incomplete := make([]*GoPackage, 0)
+	gopkg.Visit(packages, nil, func(p *GoPackage) {
+		if p.Types == nil {
+			return
+		}
+		imports[p.PkgPath] = p.Types
+		if !p.Types.Complete() && p.ExportFile != "" {
+			incomplete = append(incomplete, p)
+		}
+	})
 
-	for _, p := range u.Packages {
-		x := p.(*xpkg)
-		x.typenames.Init(u.fileset)
-		x.functions.Init(u.fileset)
-		x.constants.Init(u.fileset)
+	for _, p := range incomplete {
+		if err := readExportFile(p, imports); err != nil {
+			return fmt.Errorf("failed to read export data of `%s`: %w", p.ID, err)
+		}
 	}
+	return nil
+}
 
-	return u
+func readExportFile(p *GoPackage, imports map[string]*types.Package) error {
+	f, err := os.Open(p.ExportFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r, err := gcexportdata.NewReader(f)
+	if err != nil {
+		return err
+	}
+	_, err = gcexportdata.Read(r, p.Fset, imports, p.PkgPath)
+	return err
+}
+
+func newPackages(entries []string, fileset *token.FileSet) *Packages {
+	return &Packages{
+		entries:  entries,
+		fileset:  fileset,
+		packages: syncx.NewXmap[string, Package](),
+		modules:  syncx.NewSet[string](),
+		directs:  syncx.NewSet[string](),
+		sums:     syncx.NewXmap[string, ModuleSum](),
+		files:    syncx.NewXmap[string, Package](),
+	}
+}
+
+// register builds model of p and its imports recursively, packages in modules
+// under entries are marked as direct
+func (u *Packages) register(p *GoPackage, loaded *progress) {
+	x := newx(p)
+	x.(*xpkg).u = u
+
+	for _, path := range slices.Sorted(maps.Keys(p.Imports)) {
+		if _, ok := u.packages.Load(path); !ok {
+			u.register(p.Imports[path], loaded)
+		}
+	}
+	loaded.report(p.PkgPath)
+	// test variant `x [x.test]` shares path with x, it never replaces x
+	if _, ok := u.packages.Load(p.PkgPath); ok && p.ID != p.PkgPath {
+		for _, filename := range p.CompiledGoFiles {
+			u.files.LoadOrStore(filename, x)
+		}
+		return
+	}
+	u.packages.Store(p.PkgPath, x)
+	for _, filename := range p.CompiledGoFiles {
+		u.files.Store(filename, x)
+	}
+
+	if p.Module != nil {
+		if u.modules.Exists(p.Module.Path) {
+			u.directs.Store(p.PkgPath)
+			u.modules.Store(p.Module.Path)
+			u.sums.LoadOrStore(p.Module.Path, internal.NewSum(p.Module.Dir))
+		}
+	}
+}
+
+// index hashes direct packages into module sums and builds their models with
+// a worker pool bounded by CtxWorkers. dependencies are still built lazily. it
+// stops scheduling packages when ctx is canceled.
+func (u *Packages) index(ctx context.Context) error {
+	directs := slices.Sorted(u.Directs)
+	indexed := newProgress(ctx, StageIndexed, len(directs))
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(Workers(ctx))
+	for _, path := range directs {
+		g.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			x := u.Package(path).(*xpkg)
+			if m := x.p.Module; m != nil {
+				if s := u.ModuleSum(m.Path); s != nil {
+					s.Add(x.p)
+				}
+			}
+			x.load()
+			indexed.report(path)
+			return nil
+		})
+	}
+	return g.Wait()
 }
 
 type Packages struct {
@@ -102,6 +266,8 @@ type Packages struct {
 	modules  *syncx.Set[string]
 	directs  *syncx.Set[string]
 	sums     syncx.Map[string, ModuleSum]
+	// files maps compiled go file to its package
+	files syncx.Map[string, Package]
 }
 
 // Package locates package by path
@@ -132,10 +298,39 @@ func (u *Packages) Modules(f func(string) bool) {
 }
 
 func (u *Packages) DocByPos(pos token.Pos) []string {
-	for _, p := range u.Packages {
-		if d := p.DocByPos(pos); d != nil {
-			return d
-		}
+	if p := u.PackageAt(pos); p != nil {
+		return p.DocByPos(pos)
+	}
+	return nil
+}
+
+// PackageAt returns package whose file contains pos
+func (u *Packages) PackageAt(pos token.Pos) Package {
+	f := u.fileset.File(pos)
+	if f == nil {
+		return nil
+	}
+	p, _ := u.files.Load(f.Name())
+	return p
+}
+
+// ObjectAt returns element declared at pos, pos is position of declaration or
+// its identifier
+func (u *Packages) ObjectAt(pos token.Pos) *Element {
+	if p := u.PackageAt(pos); p != nil {
+		return p.ObjectAt(pos)
+	}
+	return nil
+}
+
+// ElementOf returns element of obj, which is a typename, function, constant,
+// method or struct field declared in loaded packages
+func (u *Packages) ElementOf(obj types.Object) *Element {
+	if obj == nil || obj.Pkg() == nil {
+		return nil
+	}
+	if p := u.Package(obj.Pkg().Path()); p != nil {
+		return p.ElementOf(obj)
 	}
 	return nil
 }
@@ -163,10 +358,28 @@ type Package interface {
 	FieldDoc(typename string, field string) []string
 
 	Eval(ast.Expr) (types.TypeAndValue, error)
+	// EvalString evaluates expr as if it appeared at pos, expr is evaluated in
+	// package scope if pos is token.NoPos, otherwise in the innermost scope
+	// containing pos
+	EvalString(expr string, pos token.Pos) (types.TypeAndValue, error)
+	// EvalConst evaluates expr in package scope, it returns error if expr is
+	// not a constant expression
+	EvalConst(expr string) (types.TypeAndValue, error)
 	Files() []*ast.File
 	FileSet() *token.FileSet
 	Position(token.Pos) token.Position
 	ObjectOf(*ast.Ident) types.Object
+	// ObjectAt returns element declared at pos
+	ObjectAt(token.Pos) *Element
+	// ElementOf returns element of types.Object declared in this package
+	ElementOf(types.Object) *Element
+	// References returns use sites of types.Object in this package
+	References(types.Object) []*Reference
+	// EnclosingDecl returns element whose declaration contains pos and the
+	// ast path to pos
+	EnclosingDecl(token.Pos) (*Element, []ast.Node)
+	// ScopeAt returns objects visible at pos
+	ScopeAt(token.Pos) []types.Object
 
 	TypeNames() TypeNames
 	Constants() Constants
@@ -182,10 +395,27 @@ func newx(p *gopkg.Package) Package {
 		constants: internal.NewMutationObjects[*types.Const, *Constant](),
 		functions: internal.NewMutationObjects[*types.Func, *Function](),
 
-		docs: syncx.NewXmap[token.Pos, []string](),
+		docs:    syncx.NewXmap[token.Pos, []string](),
+		sources: syncx.NewXmap[string, func() (*ast.File, *token.FileSet)](),
 	}
+	x.uses = newUses(x)
+	return x
+}
+
+// load builds model of package at first access
+func (x *xpkg) load() {
+	x.once.Do(x.build)
+}
+
+// build inspects syntax of package to collect objects and documents, and then
+// initializes objects and indexes
+func (x *xpkg) build() {
+	p := x.p
 	methods := make(map[types.Type][]*Function)
 
+	if len(p.Syntax) == 0 && p.Types != nil {
+		x.scan(methods)
+	}
 	for _, file := range p.Syntax {
 		ast.Inspect(file, func(node ast.Node) bool {
 			switch n := node.(type) {
@@ -201,41 +431,7 @@ func newx(p *gopkg.Package) Package {
 						}
 						var fieldsDoc map[string][]string
 						if st, ok := s.Type.(*ast.StructType); ok {
-							fieldsDoc = make(map[string][]string)
-							for _, f := range st.Fields.List {
-								d := internal.ExtractComments(f.Doc, f.Comment)
-								if len(f.Names) > 0 {
-									for _, ident := range f.Names {
-										if name := ident.String(); name != "_" {
-											fieldsDoc[name] = d
-										}
-									}
-								} else {
-									name := ""
-									typ := f.Type
-									if xt, ok := typ.(*ast.StarExpr); ok {
-										typ = xt.X
-									}
-
-									switch xt := typ.(type) {
-									case *ast.IndexExpr:
-										typ = xt.X
-									case *ast.IndexListExpr:
-										typ = xt.X
-									}
-
-									switch t := typ.(type) {
-									case *ast.Ident:
-										name = t.Name
-									case *ast.SelectorExpr:
-										name = t.Sel.Name
-									}
-
-									if len(name) > 0 && name != "_" {
-										fieldsDoc[name] = d
-									}
-								}
-							}
+							fieldsDoc = fieldDocs(st)
 						}
 						d := internal.ExtractComments(n.Doc, s.Doc, s.Comment)
 						u := p.TypesInfo.Defs[s.Name].(*types.TypeName)
@@ -306,9 +502,99 @@ func newx(p *gopkg.Package) Package {
 		t.AddMethods(methods[t.Type()]...)
 	}
 
+	x.typenames.Init(x.FileSet())
+	x.functions.Init(x.FileSet())
+	x.constants.Init(x.FileSet())
+	x.index()
+
 	// TODO inspecting signatures should contains FuncDecl, FuncLit and CallExpr
 	// TODO should analyze signatures returned results
-	return x
+}
+
+// fieldDocs collects documents of fields in struct type st, embedded field is
+// named by its type name
+func fieldDocs(st *ast.StructType) map[string][]string {
+	fieldsDoc := make(map[string][]string)
+	for _, f := range st.Fields.List {
+		d := internal.ExtractComments(f.Doc, f.Comment)
+		if len(f.Names) > 0 {
+			for _, ident := range f.Names {
+				if name := ident.String(); name != "_" {
+					fieldsDoc[name] = d
+				}
+			}
+		} else {
+			name := ""
+			typ := f.Type
+			if xt, ok := typ.(*ast.StarExpr); ok {
+				typ = xt.X
+			}
+
+			switch xt := typ.(type) {
+			case *ast.IndexExpr:
+				typ = xt.X
+			case *ast.IndexListExpr:
+				typ = xt.X
+			}
+
+			switch t := typ.(type) {
+			case *ast.Ident:
+				name = t.Name
+			case *ast.SelectorExpr:
+				name = t.Sel.Name
+			}
+
+			if len(name) > 0 && name != "_" {
+				fieldsDoc[name] = d
+			}
+		}
+	}
+	return fieldsDoc
+}
+
+// scan collects objects from scope of package loaded without syntax, such as
+// dependencies loaded from export data. objects are identified by identifiers
+// positioned at their declarations, and their documents are resolved from
+// source at first request.
+func (x *xpkg) scan(methods map[types.Type][]*Function) {
+	scope := x.p.Types.Scope()
+	for _, name := range scope.Names() {
+		obj := scope.Lookup(name)
+		if !obj.Pos().IsValid() {
+			continue
+		}
+		switch u := obj.(type) {
+		case *types.TypeName:
+			t := internal.NewTypeName(objectOf(x, u))
+			t.SetFieldDocsFunc(func() map[string][]string {
+				if _, st := x.declOf(u); st != nil {
+					return fieldDocs(st)
+				}
+				return nil
+			})
+			x.typenames.Add(t)
+			if named, ok := u.Type().(*types.Named); ok && !u.IsAlias() {
+				for m := range named.Methods() {
+					methods[named] = append(methods[named], &Function{Object: objectOf(x, m)})
+				}
+			}
+		case *types.Func:
+			x.functions.Add(&Function{Object: objectOf(x, u)})
+		case *types.Const:
+			x.constants.Add(&Constant{Object: objectOf(x, u)})
+		}
+	}
+}
+
+// objectOf creates object of u in package x without syntax, its node is a
+// synthetic identifier at position of u
+func objectOf[U internal.Exposer](x *xpkg, u U) internal.Object[U] {
+	o := any(u).(types.Object)
+	ident := &ast.Ident{NamePos: o.Pos(), Name: o.Name()}
+	return internal.NewLazyObject(ident, ident, u, func() []string {
+		d, _ := x.declOf(o)
+		return d
+	})
 }
 
 type xpkg struct {
@@ -316,8 +602,14 @@ type xpkg struct {
 	u   *Packages
 	dir *string
 	doc []string
+	// once guards model building, objects, documents and indexes are built at
+	// first access
+	once sync.Once
 
 	docs syncx.Map[token.Pos, []string]
+	// sources caches source files parsed for documents of package loaded
+	// without syntax
+	sources syncx.Map[string, func() (*ast.File, *token.FileSet)]
 
 	// fileset *token.FileSet
 	imports syncx.Map[string, Package]
@@ -326,6 +618,13 @@ type xpkg struct {
 	constants MutationConstants
 	functions MutationFunctions
 
+	// elements and positions index elements by types.Object and by position
+	// of declaration and identifier
+	elements  map[types.Object]*Element
+	positions map[token.Pos]*Element
+	// uses indexes identifiers by used object, it is built at first use
+	uses func() map[types.Object][]*ast.Ident
+
 	// TODO signatures and results
 	// signatures internal.Objects[*types.Signature, *internal.Signature]
 }
@@ -355,15 +654,23 @@ func (x *xpkg) GoModule() *gopkg.Module {
 }
 
 func (x *xpkg) PackageDoc() []string {
+	x.load()
 	return x.doc
 }
 
 func (x *xpkg) DocByPos(p token.Pos) []string {
-	d, _ := x.docs.Load(p)
-	return d
+	x.load()
+	if d, ok := x.docs.Load(p); ok {
+		return d
+	}
+	if e := x.positions[p]; e != nil && len(x.p.Syntax) == 0 {
+		return e.Doc()
+	}
+	return nil
 }
 
 func (x *xpkg) FieldDoc(t, f string) []string {
+	x.load()
 	if s := x.typenames.ElementByName(t); s != nil {
 		return s.GetFieldDocByName(f)
 	}
@@ -394,7 +701,7 @@ func (x *xpkg) SourceDir() (dir string) {
 		x.dir = new(dir)
 	}()
 
-	if x.p.Module == nil {
+	if x.p.Module == nil || x.p.Module.Dir == "" {
 		return ""
 	}
 	if x.p.PkgPath == x.p.Module.Path {
@@ -409,7 +716,22 @@ func (x *xpkg) Eval(e ast.Expr) (types.TypeAndValue, error) {
 		return types.TypeAndValue{}, err
 	}
 
-	return types.Eval(x.p.Fset, x.p.Types, e.Pos(), code.String())
+	return x.EvalString(code.String(), e.Pos())
+}
+
+func (x *xpkg) EvalString(expr string, pos token.Pos) (types.TypeAndValue, error) {
+	return types.Eval(x.p.Fset, x.p.Types, pos, expr)
+}
+
+func (x *xpkg) EvalConst(expr string) (types.TypeAndValue, error) {
+	tv, err := x.EvalString(expr, token.NoPos)
+	if err != nil {
+		return tv, err
+	}
+	if tv.Value == nil {
+		return tv, fmt.Errorf("%s (%s) is not constant", expr, tv.Type)
+	}
+	return tv, nil
 }
 
 func (x *xpkg) Files() []*ast.File {
@@ -425,17 +747,76 @@ func (x *xpkg) Position(p token.Pos) token.Position {
 }
 
 func (x *xpkg) ObjectOf(i *ast.Ident) types.Object {
+	if x.p.TypesInfo == nil {
+		return nil
+	}
 	return x.p.TypesInfo.ObjectOf(i)
 }
 
+func (x *xpkg) ObjectAt(pos token.Pos) *Element {
+	x.load()
+	return x.positions[pos]
+}
+
+func (x *xpkg) ElementOf(obj types.Object) *Element {
+	x.load()
+	return x.elements[origin(obj)]
+}
+
+// index builds element indexes after objects initialized
+func (x *xpkg) index() {
+	x.elements = make(map[types.Object]*Element)
+	x.positions = make(map[token.Pos]*Element)
+
+	add := func(e *Element, positions ...token.Pos) {
+		if _, ok := x.elements[e.Object()]; !ok {
+			x.elements[e.Object()] = e
+		}
+		for _, pos := range positions {
+			if _, ok := x.positions[pos]; !ok {
+				x.positions[pos] = e
+			}
+		}
+	}
+
+	for t := range x.typenames.Elements() {
+		add(&Element{Package: x, TypeName: t}, t.Node().Pos(), t.Ident().Pos())
+
+		methods := t.Methods().Keys()
+		slices.Sort(methods)
+		for _, name := range methods {
+			f := t.Method(name)
+			add(&Element{Package: x, TypeName: t, Function: f}, f.Node().Pos(), f.Ident().Pos())
+		}
+
+		if t.Exposer().IsAlias() {
+			continue
+		}
+		if st, ok := t.Type().Underlying().(*types.Struct); ok {
+			for f := range st.Fields() {
+				add(&Element{Package: x, TypeName: t, Field: f}, f.Pos())
+			}
+		}
+	}
+	for f := range x.functions.Elements() {
+		add(&Element{Package: x, Function: f}, f.Node().Pos(), f.Ident().Pos())
+	}
+	for c := range x.constants.Elements() {
+		add(&Element{Package: x, Constant: c}, c.Node().Pos(), c.Ident().Pos())
+	}
+}
+
 func (x *xpkg) Constants() Constants {
+	x.load()
 	return x.constants
 }
 
 func (x *xpkg) Functions() Functions {
+	x.load()
 	return x.functions
 }
 
 func (x *xpkg) TypeNames() TypeNames {
+	x.load()
 	return x.typenames
 }
@@ -3,15 +3,18 @@ package pkgx_test
 import (
 	"context"
 	"fmt"
+	"go/token"
 	"go/types"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"testing"
 
 	"github.com/xoctopus/x/contextx"
 	. "github.com/xoctopus/x/testx"
+	gopkg "golang.org/x/tools/go/packages"
 
 	. "github.com/xoctopus/pkgx/pkg/pkgx"
 	_ "github.com/xoctopus/pkgx/testdata"
@@ -295,3 +298,84 @@ func TestWithWorkdir(t *testing.T) {
 		Expect(t, o, NotBeNil[types.Object]())
 	})
 }
+
+func TestPackage_EvalString(t *testing.T) {
+	t.Run("PackageScope", func(t *testing.T) {
+		tv, err := pkg.EvalConst("IntConstTypeValue3*2 + 1")
+		Expect(t, err, Succeed())
+		Expect(t, tv.Type.String(), Equal(testdata+".IntConstType"))
+		Expect(t, tv.Value.String(), Equal("9"))
+
+		tv, err = pkg.EvalConst(`"prefix_" + "suffix"`)
+		Expect(t, err, Succeed())
+		Expect(t, tv.Value.ExactString(), Equal(`"prefix_suffix"`))
+
+		tv, err = pkg.EvalString("Structure{}.String", token.NoPos)
+		Expect(t, err, Succeed())
+		Expect(t, tv.Type.String(), Equal("func() string"))
+
+		_, err = pkg.EvalConst("ff")
+		Expect(t, err, ErrorContains("is not constant"))
+		_, err = pkg.EvalConst("Undefined * 2")
+		Expect(t, err, ErrorContains("undefined"))
+	})
+
+	t.Run("LocalScope", func(t *testing.T) {
+		f := pkg.Functions().ElementByName("F")
+		end := f.Node().End() - 1
+
+		tv, err := pkg.EvalString("s.Name()", end)
+		Expect(t, err, Succeed())
+		Expect(t, tv.Type.String(), Equal("string"))
+
+		_, err = pkg.EvalString("s.Name()", token.NoPos)
+		Expect(t, err, ErrorContains("undefined"))
+	})
+}
+
+func TestFromGoPackages(t *testing.T) {
+	load := func(mode gopkg.LoadMode, fset *token.FileSet, patterns ...string) []*GoPackage {
+		pkgs, err := gopkg.Load(&gopkg.Config{Mode: mode, Dir: dir, Fset: fset, Env: append(os.Environ(), "GOWORK=off")}, patterns...)
+		Expect(t, err, Succeed())
+		return pkgs
+	}
+
+	t.Run("Succeed", func(t *testing.T) {
+		pkgs := load(gopkg.LoadAllSyntax|gopkg.NeedModule, nil, testdata)
+		x, err := FromGoPackages(context.Background(), pkgs...)
+		Expect(t, err, Succeed())
+
+		p := x.Package(testdata)
+		Expect(t, p.GoPackage(), Equal(pkgs[0]))
+		Expect(t, p.FileSet(), Equal(pkgs[0].Fset))
+		Expect(t, x.Package(sub), NotBeNil[Package]())
+		Expect(t, slices.Sorted(x.Directs), Equal([]string{testdata, sub}))
+		Expect(t, x.ModuleSum(testdata).Hash(testdata), Equal(u.ModuleSum(testdata).Hash(testdata)))
+
+		// objects are shared with loaded packages
+		obj := pkgs[0].Types.Scope().Lookup("Structure")
+		Expect(t, x.ElementOf(obj).TypeName.Exposer(), Equal(obj.(*types.TypeName)))
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		x, err := FromGoPackages(context.Background())
+		Expect(t, err, Succeed())
+		Expect(t, slices.Collect(x.Directs), HaveLen[[]string](0))
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		_, err := FromGoPackages(context.Background(), load(gopkg.NeedName|gopkg.NeedSyntax|gopkg.NeedFiles, nil, testdata)...)
+		Expect(t, err, ErrorContains("is loaded without type info"))
+
+		_, err = FromGoPackages(context.Background(), load(gopkg.LoadSyntax, nil, "github.com/xoctopus/pkgx/testdata/not_exists")...)
+		Expect(t, err, ErrorContains("loaded package `github.com/xoctopus/pkgx/testdata/not_exists` error"))
+
+		a := load(gopkg.LoadSyntax, nil, testdata)
+		b := load(gopkg.LoadSyntax, nil, sub)
+		_, err = FromGoPackages(context.Background(), append(a, b...)...)
+		Expect(t, err, ErrorContains("different fileset"))
+
+		_, err = FromGoPackages(CtxFileset.With(context.Background(), token.NewFileSet()), a...)
+		Expect(t, err, ErrorContains("different fileset"))
+	})
+}
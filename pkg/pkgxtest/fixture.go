@@ -0,0 +1,125 @@
+// Package pkgxtest provides helpers for testing tools built on pkgx. fixtures
+// are described as txtar archives, and materialized into temporary modules.
+package pkgxtest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xoctopus/x/contextx"
+	"golang.org/x/tools/txtar"
+
+	"github.com/xoctopus/pkgx/pkg/pkgx"
+)
+
+// DefaultModule is the module path of fixture whose archive has no go.mod
+const DefaultModule = "example.com/fixture"
+
+// Fixture is a temporary module materialized from txtar archive
+type Fixture struct {
+	// Dir is root dir of fixture
+	Dir string
+	// Packages is loaded packages of fixture
+	Packages *pkgx.Packages
+}
+
+// Context returns ctx which loads packages in fixture dir offline. module
+// dependencies should be replaced to local dirs in archive. if go.work is at
+// root of fixture, packages are loaded in workspace mode.
+func (f *Fixture) Context(ctx context.Context) context.Context {
+	gowork := filepath.Join(f.Dir, "go.work")
+	if _, err := os.Stat(gowork); err == nil {
+		return contextx.Compose(
+			pkgx.CtxWorkdir.Carry(f.Dir),
+			pkgx.CtxWorkspace.Carry(gowork),
+			// -mod=mod is not allowed in workspace mode
+			pkgx.CtxEnv.Carry(append(pkgx.CtxEnv.MustFrom(ctx), "GOPROXY=off", "GOFLAGS=")),
+		)(ctx)
+	}
+	return contextx.Compose(
+		pkgx.CtxWorkdir.Carry(f.Dir),
+		pkgx.CtxEnv.Carry(append(pkgx.CtxEnv.MustFrom(ctx), "GOPROXY=off", "GOFLAGS=-mod=mod")),
+	)(ctx)
+}
+
+// Load loads packages of patterns in fixture with ctx, patterns default to
+// `./...`, or all packages of workspace modules if fixture has go.work
+func (f *Fixture) Load(ctx context.Context, patterns ...string) (*pkgx.Packages, error) {
+	ctx = f.Context(ctx)
+	if len(patterns) == 0 {
+		if pkgx.CtxWorkspace.MustFrom(ctx) != "" {
+			return pkgx.LoadWorkspace(ctx)
+		}
+		patterns = []string{"./..."}
+	}
+	return pkgx.LoadPackages(ctx, patterns...)
+}
+
+// Load materializes txtar archive data and loads packages of patterns. go.mod
+// of DefaultModule is generated if archive has neither go.mod nor go.work at
+// root, and archive with go.work is loaded in workspace mode. nested modules can be referenced by replace directives with relative
+// path, such as `replace example.com/dep => ./dep`. test fails if archive is
+// invalid or packages cannot be loaded.
+func Load(t testing.TB, data []byte, patterns ...string) *Fixture {
+	t.Helper()
+
+	f := &Fixture{Dir: Write(t, txtar.Parse(data))}
+	u, err := f.Load(context.Background(), patterns...)
+	if err != nil {
+		t.Fatalf("pkgxtest: failed to load fixture: %v", err)
+	}
+	f.Packages = u
+	return f
+}
+
+// LoadFile loads fixture from txtar archive file
+func LoadFile(t testing.TB, filename string, patterns ...string) *Fixture {
+	t.Helper()
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("pkgxtest: %v", err)
+	}
+	return Load(t, data, patterns...)
+}
+
+// Write writes files of archive to a temporary dir and returns the dir. go.mod
+// is generated as Load does
+func Write(t testing.TB, archive *txtar.Archive) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	files := append([]txtar.File{}, archive.Files...)
+	if !hasFile(archive, "go.mod") && !hasFile(archive, "go.work") {
+		files = append(files, txtar.File{
+			Name: "go.mod",
+			Data: []byte("module " + DefaultModule + "\n\ngo 1.22\n"),
+		})
+	}
+
+	for _, f := range files {
+		name := filepath.FromSlash(f.Name)
+		if !filepath.IsLocal(name) {
+			t.Fatalf("pkgxtest: invalid filename in archive: %s", f.Name)
+		}
+		filename := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+			t.Fatalf("pkgxtest: %v", err)
+		}
+		if err := os.WriteFile(filename, f.Data, 0o644); err != nil {
+			t.Fatalf("pkgxtest: %v", err)
+		}
+	}
+	return dir
+}
+
+func hasFile(archive *txtar.Archive, name string) bool {
+	for _, f := range archive.Files {
+		if filepath.Clean(f.Name) == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package pkgxtest_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/xoctopus/x/testx"
+	"golang.org/x/tools/txtar"
+
+	"github.com/xoctopus/pkgx/pkg/pkgx"
+	. "github.com/xoctopus/pkgx/pkg/pkgxtest"
+)
+
+// fatal records failure messages, and panics on fatal instead of stopping test
+type fatal struct {
+	testing.TB
+	msg  string
+	errs []string
+}
+
+func (f *fatal) Fatalf(format string, args ...any) {
+	f.msg = fmt.Sprintf(format, args...)
+	panic(f.msg)
+}
+
+func (f *fatal) Errorf(format string, args ...any) {
+	f.errs = append(f.errs, fmt.Sprintf(format, args...))
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("DefaultModule", func(t *testing.T) {
+		f := Load(t, []byte(`
+-- a.go --
+package fixture
+
+const A = 1
+-- sub/b.go --
+package sub
+
+import "example.com/fixture"
+
+const B = fixture.A + 1
+`))
+		Expect(t, f.Packages.Package(DefaultModule), NotBeNil[pkgx.Package]())
+		c := f.Packages.Lookup(DefaultModule + "/sub.B").Constant
+		Expect(t, c.Value().String(), Equal("2"))
+
+		data, err := os.ReadFile(filepath.Join(f.Dir, "go.mod"))
+		Expect(t, err, Succeed())
+		Expect(t, string(data), HavePrefix("module "+DefaultModule+"\n"))
+	})
+
+	t.Run("Modules", func(t *testing.T) {
+		f := LoadFile(t, filepath.Join("testdata", "modules.txtar"))
+		u := f.Packages
+
+		e := u.Lookup("example.com/app.Service")
+		Expect(t, e.Doc(), Equal([]string{"Service wraps dep.Client", "+gen:service"}))
+		Expect(t, u.Lookup("example.com/app/internal/store.Store"), NotBeNil[*pkgx.Element]())
+		Expect(t, u.Package("example.com/dep").SourceDir(), Equal(filepath.Join(f.Dir, "dep")))
+
+		directs := make([]string, 0)
+		for path := range u.Directs {
+			directs = append(directs, path)
+		}
+		Expect(t, directs, HaveLen[[]string](2))
+
+		u, err := f.Load(context.Background(), "example.com/dep")
+		Expect(t, err, Succeed())
+		Expect(t, u.Package("example.com/app"), BeNil[pkgx.Package]())
+	})
+
+	t.Run("Workspace", func(t *testing.T) {
+		f := Load(t, []byte(`
+-- go.work --
+go 1.22
+
+use (
+	./a
+	./b
+)
+-- a/go.mod --
+module example.com/a
+
+go 1.22
+-- a/a.go --
+package a
+
+const A = 1
+-- b/go.mod --
+module example.com/b
+
+go 1.22
+-- b/b.go --
+package b
+
+import "example.com/a"
+
+const B = a.A + 1
+`))
+		u := f.Packages
+		Expect(t, u.Lookup("example.com/b.B").Constant.Value().String(), Equal("2"))
+		Expect(t, u.ModuleOf("example.com/a").Path, Equal("example.com/a"))
+		Expect(t, u.ModuleOf("example.com/b").Path, Equal("example.com/b"))
+
+		_, err := os.Stat(filepath.Join(f.Dir, "go.mod"))
+		Expect(t, os.IsNotExist(err), BeTrue())
+
+		u, err = f.Load(context.Background(), "example.com/b")
+		Expect(t, err, Succeed())
+		Expect(t, u.Package("example.com/a").SourceDir(), Equal(filepath.Join(f.Dir, "a")))
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		ft := &fatal{TB: t}
+		ExpectPanic[string](t, func() { Load(ft, []byte("-- ../a.go --\npackage a\n")) })
+		Expect(t, ft.msg, ContainsSubString("invalid filename"))
+
+		ExpectPanic[string](t, func() { Load(ft, []byte("-- a.go --\npackage a\nvar _ = undefined\n")) })
+		Expect(t, ft.msg, ContainsSubString("failed to load fixture"))
+
+		ExpectPanic[string](t, func() { LoadFile(ft, filepath.Join("testdata", "not_found.txtar")) })
+		Expect(t, ft.msg, ContainsSubString("no such file"))
+	})
+
+	t.Run("Write", func(t *testing.T) {
+		dir := Write(t, &txtar.Archive{Files: []txtar.File{{Name: "go.mod", Data: []byte("module x\n")}}})
+		data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		Expect(t, err, Succeed())
+		Expect(t, string(data), Equal("module x\n"))
+	})
+}
@@ -0,0 +1,119 @@
+package pkgxtest
+
+import (
+	"context"
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	internal "github.com/xoctopus/pkgx/internal/pkgx"
+	"github.com/xoctopus/pkgx/pkg/genx"
+)
+
+// UpdateEnv is the environment variable enables updating golden files, such as
+// `PKGXTEST_UPDATE=1 go test ./...`
+const UpdateEnv = "PKGXTEST_UPDATE"
+
+// init registers boolean `-update` flag, so `go test -update` works for test
+// packages importing pkgxtest. it is skipped if the flag has been defined by
+// others, test packages importing pkgxtest should not define it again.
+func init() {
+	if flag.Lookup("update") == nil {
+		flag.Bool("update", false, "rewrite golden files of pkgxtest.Golden")
+	}
+}
+
+// updating reports if golden files should be rewritten. it is enabled by the
+// boolean `-update` flag or by UpdateEnv.
+func updating() bool {
+	if f := flag.Lookup("update"); f != nil {
+		if g, ok := f.Value.(flag.Getter); ok {
+			if v, ok := g.Get().(bool); ok && v {
+				return true
+			}
+		}
+	}
+	v, _ := strconv.ParseBool(os.Getenv(UpdateEnv))
+	return v
+}
+
+// GoldenDir returns dir of golden files of fixture, it is fixture filename
+// with extension replaced by `.golden`
+func GoldenDir(fixture string) string {
+	return strings.TrimSuffix(fixture, filepath.Ext(fixture)) + ".golden"
+}
+
+// Golden runs generator gen against packages of txtar fixture and compares
+// outputs with golden files under GoldenDir(fixture). golden files are placed
+// as outputs relative to fixture root. with `-update` flag or UpdateEnv,
+// golden files are rewritten by outputs.
+func Golden(t testing.TB, gen genx.Generator, fixture string) {
+	t.Helper()
+
+	f := LoadFile(t, fixture)
+	outputs, err := genx.NewRunner(gen).Generate(f.Context(context.Background()), f.Packages)
+	if err != nil {
+		t.Fatalf("pkgxtest: generator %s failed: %v", gen.Name(), err)
+	}
+
+	dir := GoldenDir(fixture)
+	update := updating()
+	if update {
+		if err = os.RemoveAll(dir); err != nil {
+			t.Fatalf("pkgxtest: %v", err)
+		}
+	}
+
+	expected := make(map[string]struct{})
+	for _, o := range outputs {
+		rel, _ := filepath.Rel(f.Dir, o.Filename)
+		filename := filepath.Join(dir, rel)
+		expected[filename] = struct{}{}
+
+		if update {
+			if err = os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+				t.Fatalf("pkgxtest: %v", err)
+			}
+			if err = os.WriteFile(filename, o.Code, 0o644); err != nil {
+				t.Fatalf("pkgxtest: %v", err)
+			}
+			continue
+		}
+
+		golden, _ := os.ReadFile(filename)
+		if diff := internal.Diff(filename, string(golden), rel+" (generated)", string(o.Code)); diff != "" {
+			t.Errorf("pkgxtest: %s: %s is different from golden file\n%s%s", o.Package, rel, diff, matched(f, o))
+		}
+	}
+
+	_ = filepath.WalkDir(dir, func(filename string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			if _, ok := expected[filename]; !ok {
+				t.Errorf("pkgxtest: golden file %s is not generated", filename)
+			}
+		}
+		return nil
+	})
+}
+
+// matched returns source of declarations which trigger generation of output
+func matched(f *Fixture, o *genx.Output) string {
+	if o.Matched.Empty() {
+		return ""
+	}
+	p := f.Packages.Package(o.Package).GoPackage()
+
+	b := strings.Builder{}
+	b.WriteString("triggered by:\n")
+	for _, t := range o.Matched.TypeNames {
+		b.WriteString(internal.SourceOfNode(p, t.Node(), true))
+	}
+	for _, fn := range o.Matched.Functions {
+		b.WriteString(internal.SourceOfNode(p, fn.Node(), true))
+	}
+	return b.String()
+}
@@ -0,0 +1,91 @@
+package pkgxtest_test
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/xoctopus/x/testx"
+
+	"github.com/xoctopus/pkgx/pkg/genx"
+	"github.com/xoctopus/pkgx/pkg/pkgx"
+	. "github.com/xoctopus/pkgx/pkg/pkgxtest"
+)
+
+// service generates `Name` method for typenames annotated by `+gen:service`
+type service struct{ err error }
+
+func (service) Name() string { return "service" }
+
+func (service) Trigger() string { return "gen:service" }
+
+func (g service) Generate(ctx context.Context, p pkgx.Package, f *genx.GenFile) error {
+	for _, t := range genx.MatchedFrom(ctx).TypeNames {
+		f.Printf("func (%s) Name() string { return %q }\n\n", t.Name(), p.Name()+"."+t.Name())
+	}
+	return g.err
+}
+
+func TestGolden(t *testing.T) {
+	Golden(t, service{}, filepath.Join("testdata", "modules.txtar"))
+
+	// copy fixture to update golden files
+	dir := t.TempDir()
+	fixture := filepath.Join(dir, "modules.txtar")
+	data, err := os.ReadFile(filepath.Join("testdata", "modules.txtar"))
+	Expect(t, err, Succeed())
+	Expect(t, os.WriteFile(fixture, data, 0o644), Succeed())
+	Expect(t, GoldenDir(fixture), Equal(filepath.Join(dir, "modules.golden")))
+
+	t.Run("Update", func(t *testing.T) {
+		for name, enable := range map[string]func(t *testing.T){
+			"Flag": func(t *testing.T) {
+				Expect(t, flag.Set("update", "true"), Succeed())
+				t.Cleanup(func() { _ = flag.Set("update", "false") })
+			},
+			"Env": func(t *testing.T) { t.Setenv(UpdateEnv, "1") },
+		} {
+			t.Run(name, func(t *testing.T) {
+				enable(t)
+
+				Expect(t, os.MkdirAll(GoldenDir(fixture), 0o755), Succeed())
+				stale := filepath.Join(GoldenDir(fixture), "stale.go")
+				Expect(t, os.WriteFile(stale, nil, 0o644), Succeed())
+
+				Golden(t, service{}, fixture)
+				_, err = os.Stat(stale)
+				Expect(t, os.IsNotExist(err), BeTrue())
+				expect, err := os.ReadFile(filepath.Join("testdata", "modules.golden", genx.Filename("service")))
+				Expect(t, err, Succeed())
+				actual, err := os.ReadFile(filepath.Join(GoldenDir(fixture), genx.Filename("service")))
+				Expect(t, err, Succeed())
+				Expect(t, actual, Equal(expect))
+			})
+		}
+	})
+
+	t.Run("Mismatched", func(t *testing.T) {
+		filename := filepath.Join(GoldenDir(fixture), genx.Filename("service"))
+		Expect(t, os.WriteFile(filename, []byte("package app\n"), 0o644), Succeed())
+		stale := filepath.Join(GoldenDir(fixture), "sub", "stale.go")
+		Expect(t, os.MkdirAll(filepath.Dir(stale), 0o755), Succeed())
+		Expect(t, os.WriteFile(stale, nil, 0o644), Succeed())
+
+		ft := &fatal{TB: t}
+		Golden(ft, service{}, fixture)
+		Expect(t, ft.errs, HaveLen[[]string](2))
+		Expect(t, ft.errs[0], ContainsSubString("example.com/app: zz_genx_service.go is different from golden file"))
+		Expect(t, ft.errs[0], ContainsSubString("+func (Service) Name() string"))
+		Expect(t, ft.errs[0], ContainsSubString("triggered by:\nService struct {"))
+		Expect(t, ft.errs[1], ContainsSubString("golden file "+stale+" is not generated"))
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		ft := &fatal{TB: t}
+		ExpectPanic[string](t, func() { Golden(ft, service{err: errors.New("any")}, fixture) })
+		Expect(t, ft.msg, ContainsSubString("generator service failed"))
+	})
+}